@@ -0,0 +1,77 @@
+package problem
+
+// Builder provides a chainable API for creating [Details] values.
+//
+// This can be used as alternative to [New] and functional options, for example when a problem is assembled over
+// multiple branches:
+//
+//	b := problem.Build("https://example.com/probs/out-of-credit", "You do not have enough credit.", 403).
+//		Detail("Your current balance is 30, but that costs 50.")
+//
+//	if account != "" {
+//		b.Instance("/account/" + account)
+//	}
+//
+//	b.Done().ServeHTTP(w, r)
+//
+// A Builder can be reused. Each call to [Builder.Done] returns a new copy of the problem built so far.
+type Builder struct {
+	d Details
+}
+
+// Build returns a new [Builder] for a problem with the given type, title and status.
+func Build(typ string, title string, status int) *Builder {
+	return &Builder{d: Details{Type: typ, Status: status, Title: title}}
+}
+
+// Status sets the Status of the problem.
+func (b *Builder) Status(status int) *Builder {
+	b.d.Status = status
+	return b
+}
+
+// Detail sets the Detail of the problem.
+func (b *Builder) Detail(detail string) *Builder {
+	b.d.Detail = detail
+	return b
+}
+
+// Instance sets the Instance of the problem.
+func (b *Builder) Instance(instance string) *Builder {
+	b.d.Instance = instance
+	return b
+}
+
+// Extension adds the given key-value pair to the Extensions of the problem.
+//
+// See also [WithExtension].
+func (b *Builder) Extension(key string, value any) *Builder {
+	return b.With(WithExtension(key, value))
+}
+
+// Header adds the given header to the Header of the problem.
+func (b *Builder) Header(key, value string) *Builder {
+	return b.With(WithHeader(key, value))
+}
+
+// Underlying sets the Underlying error of the problem.
+func (b *Builder) Underlying(err error) *Builder {
+	b.d.Underlying = err
+	return b
+}
+
+// With applies the given options to the problem.
+//
+// This can be used for options that have no dedicated method.
+func (b *Builder) With(opts ...Option) *Builder {
+	b.d.Apply(opts...)
+	return b
+}
+
+// Done returns a copy of the problem built so far.
+//
+// The returned value is independent of the builder, so the builder can be modified and reused afterward without
+// affecting previously returned values.
+func (b *Builder) Done() *Details {
+	return b.d.Clone()
+}
@@ -0,0 +1,61 @@
+package problem_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestBuilder(t *testing.T) {
+	err := errors.New("balance too low")
+
+	got := problem.Build("https://example.com/probs/out-of-credit", "You do not have enough credit.",
+		http.StatusForbidden).
+		Status(http.StatusTeapot).
+		Detail("Your current balance is 30, but that costs 50.").
+		Instance("/account/12345/msgs/abc").
+		Extension("balance", 30).
+		Header("Retry-After", "120").
+		Underlying(err).
+		With(problem.WithExtension("accounts", []string{"/account/12345", "/account/67890"})).
+		Done()
+
+	want := &problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusTeapot,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"balance":  30,
+			"accounts": []string{"/account/12345", "/account/67890"},
+		},
+		Header:     http.Header{"Retry-After": {"120"}},
+		Underlying: err,
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported, equateUnderlying); diff != "" {
+		t.Errorf("Builder.Done() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuilder_Reuse(t *testing.T) {
+	b := problem.Build("", "Forbidden", http.StatusForbidden).Extension("balance", 30)
+
+	first := b.Done()
+	first.Extensions["balance"] = 0
+
+	second := b.Extension("cost", 50).Done()
+
+	if diff := cmp.Diff(map[string]any{"balance": 0}, first.Extensions); diff != "" {
+		t.Errorf("first extensions mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(map[string]any{"balance": 30, "cost": 50}, second.Extensions); diff != "" {
+		t.Errorf("second extensions mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,55 @@
+package problem
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// IdempotencyKeyReusedTypeURI is the type URI used by [IdempotencyKeyReused].
+	IdempotencyKeyReusedTypeURI = "https://iana.org/assignments/http-problem-types#idempotency-key-reused"
+
+	// ConflictingRequestExtension is the name of the extension used by [IdempotencyKeyReused] to reference the
+	// request that originally used the idempotency key.
+	ConflictingRequestExtension = "conflicting_request"
+)
+
+// Blank returns a new [Details] without a type, using the given status and detail and [http.StatusText] as title.
+//
+// This is the canonical form for problems that have no additional semantics beyond the HTTP status code.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9457#name-aboutblank
+func Blank(status int, detail string) *Details {
+	return New("", "", status, WithTitleFromStatus(), WithDetail(detail))
+}
+
+// MethodNotAllowed returns a new [Details] for a "405 Method Not Allowed" response.
+//
+// The given methods are used as value for the Allow header, which is required for such responses.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9110#name-405-method-not-allowed
+func MethodNotAllowed(allowed ...string) *Details {
+	return New("", "", http.StatusMethodNotAllowed, WithTitleFromStatus(),
+		WithHeader("Allow", strings.Join(allowed, ", ")))
+}
+
+// Conflict returns a new [Details] for a "409 Conflict" response with the given detail and options.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9110#name-409-conflict
+func Conflict(detail string, opts ...Option) *Details {
+	d := New("", "", http.StatusConflict, WithTitleFromStatus(), WithDetail(detail))
+	return d.Apply(opts...)
+}
+
+// IdempotencyKeyReused returns a new [Details] for a request that reused an idempotency key of an earlier request.
+//
+// The problem uses [IdempotencyKeyReusedTypeURI] as type and stores the given reference to the earlier request, for
+// example its ID or URI, in the [ConflictingRequestExtension].
+//
+// See also https://datatracker.ietf.org/doc/draft-ietf-httpapi-idempotency-key-header/
+func IdempotencyKeyReused(conflictingRequest string, opts ...Option) *Details {
+	d := Conflict("The idempotency key was already used for a different request.",
+		WithExtension(ConflictingRequestExtension, conflictingRequest))
+	d.Type = IdempotencyKeyReusedTypeURI
+	return d.Apply(opts...)
+}
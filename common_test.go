@@ -0,0 +1,68 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestBlank(t *testing.T) {
+	b, err := json.Marshal(problem.Blank(http.StatusNotFound, "Account 12345 does not exist."))
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"status": 404,
+		"title": "Not Found",
+		"detail": "Account 12345 does not exist."
+	}`, b)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/", nil)
+
+	problem.MethodNotAllowed(http.MethodGet, http.MethodHead).ServeHTTP(w, r)
+
+	assertResponse(t, w, http.StatusMethodNotAllowed, `{
+		"status": 405,
+		"title": "Method Not Allowed"
+	}`)
+
+	if got, want := w.Header().Get("Allow"), "GET, HEAD"; got != want {
+		t.Errorf("got Allow %q, want %q", got, want)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+
+	problem.Conflict("Account 12345 already exists.", problem.WithInstance("/accounts/12345")).ServeHTTP(w, r)
+
+	assertResponse(t, w, http.StatusConflict, `{
+		"status": 409,
+		"title": "Conflict",
+		"detail": "Account 12345 already exists.",
+		"instance": "/accounts/12345"
+	}`)
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	problem.IdempotencyKeyReused("/requests/abc").ServeHTTP(w, r)
+
+	assertResponse(t, w, http.StatusConflict, `{
+		"type": "https://iana.org/assignments/http-problem-types#idempotency-key-reused",
+		"status": 409,
+		"title": "Conflict",
+		"detail": "The idempotency key was already used for a different request.",
+		"conflicting_request": "/requests/abc"
+	}`)
+}
@@ -0,0 +1,116 @@
+// Package connectproblem implements conversion of Connect and gRPC-Web errors into RFC 9457 problem details.
+//
+// This package lives in its own module so that the core problem module does not depend on connectrpc.com/connect.
+package connectproblem
+
+import (
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+
+	"github.com/nussjustin/problem"
+)
+
+const (
+	// CodeExtension is the name of the extension containing the Connect error code.
+	CodeExtension = "code"
+
+	// DetailsExtension is the name of the extension containing the Connect error details, if any.
+	DetailsExtension = "details"
+)
+
+// ErrorDetail describes a single Connect error detail as part of the [DetailsExtension].
+type ErrorDetail struct {
+	// Type is the fully-qualified protobuf type name of the detail.
+	Type string `json:"type"`
+
+	// Value contains the protobuf-encoded value of the detail.
+	Value []byte `json:"value"`
+}
+
+// FromConnectError returns a new [problem.Details] describing the given Connect error.
+//
+// The Connect code is mapped to an HTTP status as defined by the Connect protocol and used as status and, via
+// [http.StatusText], as title. The code itself is stored in the [CodeExtension].
+//
+// The error message is used as detail and any error details are stored in the [DetailsExtension].
+//
+// The returned value wraps err.
+//
+// See also https://connectrpc.com/docs/protocol/#error-codes
+func FromConnectError(err *connect.Error) *problem.Details {
+	status := HTTPStatus(err.Code())
+
+	d := problem.New("", http.StatusText(status), status,
+		problem.WithDetail(err.Message()),
+		problem.WithExtension(CodeExtension, err.Code().String()),
+		problem.WithUnderlying(err))
+
+	if details := err.Details(); len(details) > 0 {
+		converted := make([]ErrorDetail, len(details))
+
+		for i, detail := range details {
+			converted[i] = ErrorDetail{Type: detail.Type(), Value: detail.Bytes()}
+		}
+
+		d.Extensions[DetailsExtension] = converted
+	}
+
+	return d
+}
+
+// FromError is like [FromConnectError], but accepts any error.
+//
+// If err can not be converted to a [*connect.Error] using [errors.As], nil is returned.
+func FromError(err error) *problem.Details {
+	var connectErr *connect.Error
+
+	if !errors.As(err, &connectErr) {
+		return nil
+	}
+
+	return FromConnectError(connectErr)
+}
+
+// HTTPStatus returns the HTTP status code for the given Connect code as defined by the Connect protocol.
+//
+// Unknown codes are mapped to [http.StatusInternalServerError].
+func HTTPStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return 499 // Client Closed Request
+	case connect.CodeUnknown:
+		return http.StatusInternalServerError
+	case connect.CodeInvalidArgument:
+		return http.StatusBadRequest
+	case connect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeFailedPrecondition:
+		return http.StatusBadRequest
+	case connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case connect.CodeInternal:
+		return http.StatusInternalServerError
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeDataLoss:
+		return http.StatusInternalServerError
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
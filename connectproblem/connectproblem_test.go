@@ -0,0 +1,90 @@
+package connectproblem_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/nussjustin/problem"
+	"github.com/nussjustin/problem/connectproblem"
+)
+
+func TestFromConnectError(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Error *connect.Error
+		Want  *problem.Details
+	}{
+		{
+			Name:  "Not found",
+			Error: connect.NewError(connect.CodeNotFound, errors.New("account 12345 not found")),
+			Want: &problem.Details{
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "account 12345 not found",
+				Extensions: map[string]any{
+					connectproblem.CodeExtension: "not_found",
+				},
+			},
+		},
+		{
+			Name:  "Resource exhausted",
+			Error: connect.NewError(connect.CodeResourceExhausted, errors.New("too many requests")),
+			Want: &problem.Details{
+				Title:  "Too Many Requests",
+				Status: http.StatusTooManyRequests,
+				Detail: "too many requests",
+				Extensions: map[string]any{
+					connectproblem.CodeExtension: "resource_exhausted",
+				},
+			},
+		},
+		{
+			Name:  "Canceled",
+			Error: connect.NewError(connect.CodeCanceled, errors.New("request canceled")),
+			Want: &problem.Details{
+				Status: 499,
+				Detail: "request canceled",
+				Extensions: map[string]any{
+					connectproblem.CodeExtension: "canceled",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := connectproblem.FromConnectError(test.Error)
+
+			opts := []cmp.Option{
+				cmpopts.IgnoreFields(problem.Details{}, "Underlying"),
+				cmpopts.IgnoreUnexported(problem.Details{}),
+			}
+
+			if diff := cmp.Diff(test.Want, got, opts...); diff != "" {
+				t.Errorf("FromConnectError() mismatch (-want +got):\n%s", diff)
+			}
+
+			if !errors.Is(got, test.Error) {
+				t.Errorf("errors.Is() returned false, want true")
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	err := fmt.Errorf("calling service: %w", connect.NewError(connect.CodeUnavailable, errors.New("unavailable")))
+
+	if got := connectproblem.FromError(err); got == nil || got.Status != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want details with status %d", got, http.StatusServiceUnavailable)
+	}
+
+	if got := connectproblem.FromError(errors.New("not a connect error")); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
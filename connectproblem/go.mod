@@ -0,0 +1,18 @@
+module github.com/nussjustin/problem/connectproblem
+
+go 1.24
+
+require (
+	connectrpc.com/connect v1.18.1
+	github.com/google/go-cmp v0.7.0
+	github.com/nussjustin/problem v0.1.0
+)
+
+require (
+	github.com/go-json-experiment/json v0.0.0-20250813233538-9b1f9ea2e11b // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+// Build against the local copy of the core module during development. Consumers of this module are not affected by
+// this directive and use the version required above.
+replace github.com/nussjustin/problem => ../
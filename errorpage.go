@@ -0,0 +1,67 @@
+package problem
+
+import (
+	"bytes"
+	"cmp"
+	"html/template"
+	"net/http"
+)
+
+// ErrorPageRenderer serves problems as HTML pages to clients that prefer text/html and as JSON to all other clients.
+//
+// Unlike a [http.Handler], the problem to serve is passed on each call to [ErrorPageRenderer.Serve].
+//
+// The zero value is not usable. Template must be set before use.
+type ErrorPageRenderer struct {
+	// Template is used to render the HTML page. It is executed with the [*Details] being served as data.
+	//
+	// Since this is a [html/template.Template], all fields are escaped automatically.
+	Template *template.Template
+
+	// Options are passed to [Serve] when responding with JSON.
+	Options []EncodeOption
+}
+
+// Serve writes d to w, either as an HTML page rendered using e.Template or as JSON, depending on the Accept header
+// of r.
+//
+// HTML is only used if the client explicitly lists text/html in its Accept header and does not prefer a JSON media
+// type over it. If the template can not be executed, the problem is served as JSON instead.
+func (e *ErrorPageRenderer) Serve(w http.ResponseWriter, r *http.Request, d *Details) {
+	w.Header().Add("Vary", "Accept")
+
+	if !prefersHTML(r.Header.Get("Accept")) {
+		Serve(w, r, d, e.Options...)
+		return
+	}
+
+	var buf bytes.Buffer
+
+	if err := e.Template.Execute(&buf, d); err != nil {
+		Serve(w, r, d, e.Options...)
+		return
+	}
+
+	hdr := w.Header()
+
+	for k, vs := range d.Header {
+		for _, v := range vs {
+			hdr.Add(k, v)
+		}
+	}
+
+	hdr.Del("Content-Length")
+	hdr.Set("Content-Type", "text/html; charset=utf-8")
+	hdr.Set("X-Content-Type-Options", "nosniff")
+
+	w.WriteHeader(cmp.Or(newEncodeOptions(e.Options).status(d), http.StatusInternalServerError))
+
+	_, _ = w.Write(buf.Bytes())
+}
+
+// prefersHTML reports whether the given Accept header value explicitly lists text/html with a quality at least as
+// high as any JSON media type.
+func prefersHTML(accept string) bool {
+	htmlQ := acceptQuality(accept, "text/html")
+	return htmlQ > 0 && htmlQ >= acceptQuality(accept, ContentType, "application/json")
+}
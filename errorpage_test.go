@@ -0,0 +1,100 @@
+package problem_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nussjustin/problem"
+)
+
+var errorPageTemplate = template.Must(template.New("").Parse(`<h1>{{.Status}} {{.Title}}</h1><p>{{.Detail}}</p>`))
+
+func TestErrorPageRenderer(t *testing.T) {
+	d := &problem.Details{
+		Status: http.StatusNotFound,
+		Title:  "Not Found",
+		Detail: "<script>alert(1)</script>",
+	}
+
+	e := &problem.ErrorPageRenderer{Template: errorPageTemplate}
+
+	t.Run("HTML", func(t *testing.T) {
+		accepts := []string{
+			"text/html",
+			"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			"application/problem+json;q=0.5, text/html",
+		}
+
+		for _, accept := range accepts {
+			t.Run(accept, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+
+				e.Serve(w, r, d)
+
+				if got, want := w.Code, http.StatusNotFound; got != want {
+					t.Errorf("got status %d, want %d", got, want)
+				}
+
+				if got, want := w.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+					t.Errorf("got Content-Type %q, want %q", got, want)
+				}
+
+				if got, want := w.Header().Get("Vary"), "Accept"; got != want {
+					t.Errorf("got Vary %q, want %q", got, want)
+				}
+
+				want := `<h1>404 Not Found</h1><p>&lt;script&gt;alert(1)&lt;/script&gt;</p>`
+
+				if got := w.Body.String(); got != want {
+					t.Errorf("got body %s, want %s", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		accepts := []string{
+			"",
+			"*/*",
+			"application/json",
+			"application/problem+json, text/html;q=0.9",
+			"text/html;q=0",
+		}
+
+		for _, accept := range accepts {
+			t.Run(accept, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+
+				e.Serve(w, r, d)
+
+				assertResponse(t, w, http.StatusNotFound, `{
+					"status": 404,
+					"title": "Not Found",
+					"detail": "<script>alert(1)</script>"
+				}`)
+			})
+		}
+	})
+
+	t.Run("Template error", func(t *testing.T) {
+		e := &problem.ErrorPageRenderer{Template: template.Must(template.New("").Parse(`{{.Missing}}`))}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "text/html")
+
+		e.Serve(w, r, d)
+
+		assertResponse(t, w, http.StatusNotFound, `{
+			"status": 404,
+			"title": "Not Found",
+			"detail": "<script>alert(1)</script>"
+		}`)
+	})
+}
@@ -0,0 +1,179 @@
+package problem
+
+import (
+	stdjson "encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/go-json-experiment/json"
+)
+
+const (
+	// SupportExtension is the name of the extension used to store [Support] information.
+	//
+	// See [WithSupport] and [Details.Support].
+	SupportExtension = "support"
+)
+
+// Support contains contact information that clients can show to users to get help with a problem.
+type Support struct {
+	// Email is an email address that can be contacted for support.
+	Email string `json:"email,omitempty"`
+
+	// URL is the URL of a support page or contact form.
+	URL string `json:"url,omitempty"`
+}
+
+// WithSupport sets the [SupportExtension] for a new Details value.
+//
+// WithSupport panics if both email and url are empty.
+func WithSupport(email, url string) Option {
+	if email == "" && url == "" {
+		panic("problem: support requires an email or url")
+	}
+
+	return WithExtension(SupportExtension, Support{Email: email, URL: url})
+}
+
+// Support returns the support information set via [WithSupport] or parsed from JSON, if any.
+//
+// If the extension is not set or has an invalid format, the second return value is false.
+func (d *Details) Support() (Support, bool) {
+	switch v := d.Extensions[SupportExtension].(type) {
+	case Support:
+		return v, true
+	case map[string]any:
+		email, _ := v["email"].(string)
+		url, _ := v["url"].(string)
+
+		if email == "" && url == "" {
+			return Support{}, false
+		}
+
+		return Support{Email: email, URL: url}, true
+	default:
+		return Support{}, false
+	}
+}
+
+// Extension returns the extension with the given key as value of type T.
+//
+// If the extension does not exist or is not of type T, the second return value is false. No conversion is done, so
+// for problems parsed from JSON, for example via [From], numbers are of type float64 (or [encoding/json.Number] when
+// using [WithJSONNumbers]), arrays of type []any and objects of type map[string]any. See also [ExtensionInt] and
+// [ExtensionSlice].
+func Extension[T any](d *Details, key string) (T, bool) {
+	v, ok := d.Extensions[key].(T)
+	return v, ok
+}
+
+// ExtensionInt returns the extension with the given key as int.
+//
+// In addition to values of type int, values of any other integer type and float64 values without a fractional part,
+// as created when parsing JSON, are accepted, as long as the value fits into an int. The same applies to values of
+// type [encoding/json.Number], as created when using [WithJSONNumbers].
+//
+// If the extension does not exist or can not be converted, the second return value is false.
+func ExtensionInt(d *Details, key string) (int, bool) {
+	return intValue(d.Extensions[key])
+}
+
+// intValue returns v as int, if possible. See [ExtensionInt] for the accepted values.
+func intValue(v any) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		if v < math.MinInt || v > math.MaxInt {
+			return 0, false
+		}
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case uint32:
+		if uint64(v) > math.MaxInt {
+			return 0, false
+		}
+		return int(v), true
+	case uint:
+		if uint64(v) > math.MaxInt {
+			return 0, false
+		}
+		return int(v), true
+	case uint64:
+		if v > math.MaxInt {
+			return 0, false
+		}
+		return int(v), true
+	case float64:
+		return floatInt(v)
+	case stdjson.Number:
+		return numberInt(v)
+	default:
+		return 0, false
+	}
+}
+
+// floatInt returns f as int if f has no fractional part and fits into an int.
+func floatInt(f float64) (int, bool) {
+	if f != math.Trunc(f) || f < math.MinInt || f >= math.MaxInt {
+		return 0, false
+	}
+
+	return int(f), true
+}
+
+// numberInt returns n as int if n is an integer that fits into an int.
+//
+// Numbers using a fraction or exponent, like 1.0 or 1e3, are accepted as long as their value is an integer.
+func numberInt(n stdjson.Number) (int, bool) {
+	if i, err := strconv.ParseInt(string(n), 10, 0); err == nil {
+		return int(i), true
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return 0, false
+	}
+
+	return floatInt(f)
+}
+
+// ExtensionSlice returns the extension with the given key as slice of T.
+//
+// If the extension is already of type []T, it is returned as is. Otherwise, the value is converted by encoding it
+// as JSON and decoding the result into a []T. This is mainly useful for problems parsed from JSON, for example via
+// [From], where arrays of objects are decoded as []any containing map[string]any values.
+//
+// If the extension does not exist or can not be converted, the second return value is false.
+func ExtensionSlice[T any](d *Details, key string) ([]T, bool) {
+	v, ok := d.Extensions[key]
+	if !ok {
+		return nil, false
+	}
+
+	if s, ok := v.([]T); ok {
+		return s, true
+	}
+
+	b, err := json.Marshal(v, json.WithMarshalers(numberMarshalers))
+	if err != nil {
+		return nil, false
+	}
+
+	var s []T
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, false
+	}
+
+	return s, true
+}
@@ -0,0 +1,202 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestExtension(t *testing.T) {
+	d, err := problem.ParseString(problem.ContentType, `{
+		"title": "You do not have enough credit.",
+		"account": "/account/12345",
+		"balance": 30
+	}`, 0)
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	if got, ok := problem.Extension[string](d, "account"); got != "/account/12345" || !ok {
+		t.Errorf("Extension() = %q, %t, want %q, true", got, ok, "/account/12345")
+	}
+
+	if got, ok := problem.Extension[float64](d, "balance"); got != 30 || !ok {
+		t.Errorf("Extension() = %v, %t, want 30, true", got, ok)
+	}
+
+	if got, ok := problem.Extension[int](d, "balance"); ok {
+		t.Errorf("Extension() = %v, true for float64 extension, want false", got)
+	}
+
+	if got, ok := problem.Extension[string](d, "missing"); ok {
+		t.Errorf("Extension() = %q, true for missing extension, want false", got)
+	}
+}
+
+func TestExtensionInt(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Value    any
+		Expected int
+		OK       bool
+	}{
+		{Name: "int", Value: 30, Expected: 30, OK: true},
+		{Name: "int64", Value: int64(-30), Expected: -30, OK: true},
+		{Name: "uint8", Value: uint8(30), Expected: 30, OK: true},
+		{Name: "uint64 overflow", Value: uint64(math.MaxUint64), OK: false},
+		{Name: "float64", Value: float64(30), Expected: 30, OK: true},
+		{Name: "float64 fraction", Value: 30.5, OK: false},
+		{Name: "float64 overflow", Value: 1e300, OK: false},
+		{Name: "float64 NaN", Value: math.NaN(), OK: false},
+		{Name: "string", Value: "30", OK: false},
+		{Name: "nil", Value: nil, OK: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := problem.New("", "Forbidden", http.StatusForbidden, problem.WithExtension("balance", test.Value))
+
+			got, ok := problem.ExtensionInt(d, "balance")
+			if got != test.Expected || ok != test.OK {
+				t.Errorf("ExtensionInt() = %d, %t, want %d, %t", got, ok, test.Expected, test.OK)
+			}
+		})
+	}
+
+	t.Run("Parsed", func(t *testing.T) {
+		d, err := problem.ParseString(problem.ContentType, `{"balance": 30}`, 0)
+		if err != nil {
+			t.Fatalf("failed to parse details: %s", err)
+		}
+
+		if got, ok := problem.ExtensionInt(d, "balance"); got != 30 || !ok {
+			t.Errorf("ExtensionInt() = %d, %t, want 30, true", got, ok)
+		}
+	})
+}
+
+func TestExtensionSlice(t *testing.T) {
+	d, err := problem.ParseString(problem.ContentType, `{
+		"type": "https://example.net/validation-error",
+		"invalid-params": [
+			{"name": "age", "reason": "must be a positive integer"},
+			{"name": "color", "reason": "must be 'green', 'red' or 'blue'"}
+		],
+		"balance": 30
+	}`, 0)
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	want := []problem.InvalidParam{
+		{Name: "age", Reason: "must be a positive integer"},
+		{Name: "color", Reason: "must be 'green', 'red' or 'blue'"},
+	}
+
+	got, ok := problem.ExtensionSlice[problem.InvalidParam](d, problem.InvalidParamsExtension)
+	if !ok {
+		t.Fatal("ExtensionSlice() returned false")
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExtensionSlice() mismatch (-want +got):\n%s", diff)
+	}
+
+	d = problem.NewValidationBuilder(validationProblemType).Done()
+	d.Extensions = map[string]any{problem.InvalidParamsExtension: want}
+
+	got, ok = problem.ExtensionSlice[problem.InvalidParam](d, problem.InvalidParamsExtension)
+	if !ok {
+		t.Fatal("ExtensionSlice() returned false for typed slice")
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExtensionSlice() mismatch for typed slice (-want +got):\n%s", diff)
+	}
+
+	if _, ok := problem.ExtensionSlice[problem.InvalidParam](d, "missing"); ok {
+		t.Error("ExtensionSlice() returned true for missing extension")
+	}
+
+	d.Extensions["balance"] = 30
+
+	if _, ok := problem.ExtensionSlice[problem.InvalidParam](d, "balance"); ok {
+		t.Error("ExtensionSlice() returned true for non-slice extension")
+	}
+
+	t.Run("JSON numbers", func(t *testing.T) {
+		d, err := problem.ParseString(problem.ContentType, `{"accounts": [{"id": 12345}, {"id": 67890}]}`, 0,
+			problem.WithJSONNumbers())
+		if err != nil {
+			t.Fatalf("failed to parse details: %s", err)
+		}
+
+		type account struct {
+			ID int `json:"id"`
+		}
+
+		got, ok := problem.ExtensionSlice[account](d, "accounts")
+		if !ok {
+			t.Fatal("ExtensionSlice() returned false")
+		}
+
+		if diff := cmp.Diff([]account{{ID: 12345}, {ID: 67890}}, got); diff != "" {
+			t.Errorf("ExtensionSlice() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestWithSupport(t *testing.T) {
+	d := problem.New(
+		"https://example.com/probs/account-locked",
+		"Your account is locked.",
+		http.StatusForbidden,
+		problem.WithSupport("support@example.com", "https://example.com/support"))
+
+	want := problem.Support{Email: "support@example.com", URL: "https://example.com/support"}
+
+	if got, ok := d.Support(); !ok || got != want {
+		t.Errorf("got %v, %t, want %v, true", got, ok, want)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"type": "https://example.com/probs/account-locked",
+		"title": "Your account is locked.",
+		"status": 403,
+		"support": {"email": "support@example.com", "url": "https://example.com/support"}
+	}`, b)
+
+	var parsed problem.Details
+
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		t.Fatalf("failed to unmarshal details: %s", err)
+	}
+
+	if got, ok := parsed.Support(); !ok || got != want {
+		t.Errorf("got %v, %t after round-trip, want %v, true", got, ok, want)
+	}
+
+	if _, ok := (&problem.Details{}).Support(); ok {
+		t.Errorf("got support for empty details")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic was raised for empty support")
+			}
+		}()
+
+		problem.WithSupport("", "")
+	}()
+}
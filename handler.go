@@ -1,8 +1,13 @@
 package problem
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"slices"
 )
 
 // InternalServerError is used by [Handler] to serve as response if no callback is defined.
@@ -11,13 +16,121 @@ var InternalServerError = &Details{
 	Title:  "Internal Server Error",
 }
 
+// InternalDetail is the generic detail used by [Internal].
+const InternalDetail = "An unexpected error occurred while processing the request."
+
+// Internal returns a copy of [InternalServerError] with the Detail set to [InternalDetail] and the Underlying error
+// set to err.
+//
+// The Detail is intentionally generic, so that no information about the underlying error is leaked to clients.
+func Internal(err error) *Details {
+	d := copyInternalServerError(err)
+	d.Detail = InternalDetail
+	return d
+}
+
+func copyInternalServerError(err error) *Details {
+	d := InternalServerError.Clone()
+	d.Underlying = err
+	return d
+}
+
+// StackMeta is the metadata key under which [Handler] stores the stack trace of a recovered panic as string.
+//
+// See also [Details.Meta].
+const StackMeta = "stack"
+
+// HandlerOption defines options for [Handler] and [HandlerFunc].
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	logger       *slog.Logger
+	bodyStatuses []int
+	fallback     *Details
+	mapper       func(recovered any) *Details
+	panicHook    func(r *http.Request, recovered any, stack []byte)
+}
+
+// WithLogger sets a logger that is used by [Handler] to log recovered panics that are not problems.
+//
+// Panics are logged at [slog.LevelError] with the same attributes as used by [Details.Log] and the stack trace of the
+// panic as additional attribute named "stack".
+//
+// When used with [HandlerFunc], returned errors that are not problems are logged the same way, but without a stack
+// trace.
+func WithLogger(logger *slog.Logger) HandlerOption {
+	return func(o *handlerOptions) {
+		o.logger = logger
+	}
+}
+
+// WithBodyForStatuses restricts [Handler] to only include the problem as body for responses with one of the given
+// statuses.
+//
+// For all other statuses, only the status and the headers from [Details.Header] are written. This can be used for
+// APIs that do not want to include a body in some responses, for example in "401 Unauthorized" responses.
+//
+// By default, a body is included for all statuses.
+func WithBodyForStatuses(statuses ...int) HandlerOption {
+	return func(o *handlerOptions) {
+		o.bodyStatuses = append(o.bodyStatuses, statuses...)
+	}
+}
+
+// WithFallback sets the problem served by [Handler] for recovered panics that are not problems, instead of
+// [InternalServerError].
+//
+// As with InternalServerError, a copy of the fallback is served, so the given value is never modified.
+func WithFallback(fallback *Details) HandlerOption {
+	return func(o *handlerOptions) {
+		o.fallback = fallback
+	}
+}
+
+// WithMapper sets a function that is used by [Handler] to convert recovered panics into problems.
+//
+// The mapper is called with the recovered value before checking for a wrapped [Details] value and can be used to
+// translate domain specific errors, for example sql.ErrNoRows, into problems. If the mapper returns nil, the
+// recovered value is handled as if no mapper was set.
+func WithMapper(mapper func(recovered any) *Details) HandlerOption {
+	return func(o *handlerOptions) {
+		o.mapper = mapper
+	}
+}
+
+// WithPanicHook sets a function that is called by [Handler] for each recovered panic, including panics with problems,
+// before the response is written.
+//
+// The hook is called with the request, the recovered value and the stack trace of the panic as returned by
+// [debug.Stack] and can be used to report panics, for example to an error tracking service. Panics inside the hook
+// are recovered and ignored.
+func WithPanicHook(hook func(r *http.Request, recovered any, stack []byte)) HandlerOption {
+	return func(o *handlerOptions) {
+		o.panicHook = hook
+	}
+}
+
 // Handler wraps the given http.Handler and automatically recovers panics from given handler.
 //
 // When recovering from a panic, if the recovered value is an error, the handler will first try converting it into a
 // value of type *Details using [errors.As] and, if successful, serve the value using [Details.ServeHTTP].
 //
-// Otherwise [InternalServerError] is served as response.
-func Handler(next http.Handler) http.Handler {
+// If a mapper was set using [WithMapper], it is called with the recovered value before that and, if it returns a
+// non-nil value, the returned problem is served instead.
+//
+// Otherwise a copy of [InternalServerError] or the fallback set using [WithFallback] is served as response. The
+// copy has the recovered value as Underlying error and the stack trace of the panic stored as metadata under
+// [StackMeta], which can be logged using [WithLogger]. Neither is included in the response.
+//
+// As a special case, if the recovered value is [http.ErrAbortHandler], the panic is re-raised without writing a
+// response so that the server can abort the response as usual.
+func Handler(next http.Handler, opts ...HandlerOption) http.Handler {
+	var o handlerOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			recovered := recover()
@@ -25,19 +138,122 @@ func Handler(next http.Handler) http.Handler {
 				return
 			}
 
-			var details *Details
-
-			if err, ok := recovered.(error); ok {
-				errors.As(err, &details)
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
 			}
 
-			if details == nil {
-				details = InternalServerError
+			stack := debug.Stack()
+
+			if o.panicHook != nil {
+				o.callPanicHook(r, recovered, stack)
 			}
 
-			details.ServeHTTP(w, r)
+			o.serve(w, r, o.details(r, recovered, stack))
 		}()
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// HandlerFunc returns a [http.Handler] that calls fn and serves a problem for any error returned by fn.
+//
+// Returned errors are handled the same way as recovered panics in [Handler]: If the error is or wraps a *Details,
+// as reported by [errors.As], that value is served. Otherwise a copy of [InternalServerError] or the fallback set
+// using [WithFallback] is served, with the error as Underlying error.
+//
+// If fn returns nil, it is assumed that fn has already written a response.
+//
+// Panics in fn are not recovered. Use [Handler] to also handle panics.
+func HandlerFunc(fn func(http.ResponseWriter, *http.Request) error, opts ...HandlerOption) http.Handler {
+	var o handlerOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			o.serve(w, r, o.details(r, err, nil))
+		}
+	})
+}
+
+// details returns the problem to serve for the given recovered value or returned error.
+//
+// If stack is not nil, it is stored as metadata and logged together with the problem.
+func (o *handlerOptions) details(r *http.Request, v any, stack []byte) *Details {
+	var details *Details
+
+	if o.mapper != nil {
+		details = o.mapper(v)
+	}
+
+	err, ok := v.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", v)
+	} else if details == nil {
+		errors.As(err, &details)
+	}
+
+	if details != nil {
+		return details
+	}
+
+	details = o.fallbackDetails(err)
+
+	if stack != nil {
+		WithMeta(StackMeta, string(stack))(details)
+	}
+
+	if o.logger != nil {
+		attrs := details.logAttrs()
+
+		if stack != nil {
+			attrs = append(attrs, slog.String("stack", string(stack)))
+		}
+
+		o.logger.LogAttrs(r.Context(), slog.LevelError, details.Title, attrs...)
+	}
+
+	return details
+}
+
+// serve writes the given problem as response, taking into account [WithBodyForStatuses].
+func (o *handlerOptions) serve(w http.ResponseWriter, r *http.Request, details *Details) {
+	status := cmp.Or(details.Status, http.StatusInternalServerError)
+
+	if o.bodyStatuses != nil && !slices.Contains(o.bodyStatuses, status) {
+		h := w.Header()
+
+		for k, vs := range details.Header {
+			for _, v := range vs {
+				h.Add(k, v)
+			}
+		}
+
+		w.WriteHeader(status)
+		return
+	}
+
+	details.ServeHTTP(w, r)
+}
+
+// fallbackDetails returns a copy of the configured fallback or of [InternalServerError] with err as Underlying error.
+func (o *handlerOptions) fallbackDetails(err error) *Details {
+	if o.fallback == nil {
+		return copyInternalServerError(err)
+	}
+
+	d := o.fallback.Clone()
+	d.Underlying = err
+	return d
+}
+
+// callPanicHook calls the configured panic hook, ignoring any panic raised by the hook itself.
+func (o *handlerOptions) callPanicHook(r *http.Request, recovered any, stack []byte) {
+	defer func() {
+		_ = recover()
+	}()
+
+	o.panicHook(r, recovered, stack)
+}
@@ -2,9 +2,13 @@ package problem_test
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/nussjustin/problem"
@@ -130,3 +134,370 @@ func TestHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ErrAbortHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("got panic %v, want %v", recovered, http.ErrAbortHandler)
+		}
+
+		if w.Body.Len() > 0 {
+			t.Errorf("data was written")
+		}
+	}()
+
+	problem.Handler(panicHandler(http.ErrAbortHandler)).ServeHTTP(w, r)
+}
+
+func TestHandler_WithLogger(t *testing.T) {
+	var h recordHandler
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	err := errors.New("database connection lost")
+
+	problem.Handler(panicHandler(err), problem.WithLogger(slog.New(&h))).ServeHTTP(w, r)
+
+	assertResponse(t, w, http.StatusInternalServerError, `{
+		"status": 500,
+		"title": "Internal Server Error"
+	}`)
+
+	if len(h.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(h.records))
+	}
+
+	record := h.records[0]
+
+	if got, want := record.Level, slog.LevelError; got != want {
+		t.Errorf("got level %s, want %s", got, want)
+	}
+
+	attrs := make(map[string]slog.Value)
+
+	record.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+
+	if got := attrs["error"].Any(); got != err {
+		t.Errorf("got error %v, want %v", got, err)
+	}
+
+	if got := attrs["stack"].String(); !strings.Contains(got, "panicHandler") {
+		t.Errorf("got stack %q, want stack containing panicHandler", got)
+	}
+}
+
+func TestHandler_NonErrorPanic(t *testing.T) {
+	var h recordHandler
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	problem.Handler(panicHandler("boom"), problem.WithLogger(slog.New(&h))).ServeHTTP(w, r)
+
+	if len(h.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(h.records))
+	}
+
+	h.records[0].Attrs(func(a slog.Attr) bool {
+		if a.Key == "error" {
+			if got, want := a.Value.Any().(error).Error(), "panic: boom"; got != want {
+				t.Errorf("got error %q, want %q", got, want)
+			}
+		}
+		return true
+	})
+}
+
+func TestHandler_WithBodyForStatuses(t *testing.T) {
+	handler := problem.Handler(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+
+			panic(problem.New("", http.StatusText(status), status,
+				problem.WithHeader("WWW-Authenticate", `Bearer realm="example"`)))
+		}),
+		problem.WithBodyForStatuses(http.StatusForbidden))
+
+	t.Run("Without body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/?status=401", nil)
+
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, http.StatusUnauthorized; got != want {
+			t.Errorf("got status %d, want %d", got, want)
+		}
+
+		if got, want := w.Header().Get("WWW-Authenticate"), `Bearer realm="example"`; got != want {
+			t.Errorf("got WWW-Authenticate %q, want %q", got, want)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != "" {
+			t.Errorf("got Content-Type %q, want none", got)
+		}
+
+		if w.Body.Len() > 0 {
+			t.Errorf("got body %q, want none", w.Body.String())
+		}
+	})
+
+	t.Run("With body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/?status=403", nil)
+
+		handler.ServeHTTP(w, r)
+
+		assertResponse(t, w, http.StatusForbidden, `{
+			"status": 403,
+			"title": "Forbidden"
+		}`)
+	})
+}
+
+func TestHandler_WithFallback(t *testing.T) {
+	fallback := problem.New("", "Service Unavailable", http.StatusServiceUnavailable,
+		problem.WithDetail("Please try again later."))
+
+	handler := problem.Handler(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(errors.New("database connection lost"))
+		}),
+		problem.WithFallback(fallback))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	handler.ServeHTTP(w, r)
+
+	assertResponse(t, w, http.StatusServiceUnavailable, `{
+		"status": 503,
+		"title": "Service Unavailable",
+		"detail": "Please try again later."
+	}`)
+
+	if fallback.Underlying != nil {
+		t.Errorf("fallback was modified: got underlying error %v", fallback.Underlying)
+	}
+}
+
+func TestHandler_WithMapper(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	handler := problem.Handler(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/not-found":
+				panic(fmt.Errorf("loading user: %w", errNotFound))
+			case "/details":
+				panic(problem.New("", "Forbidden", http.StatusForbidden))
+			default:
+				panic(errors.New("database connection lost"))
+			}
+		}),
+		problem.WithMapper(func(recovered any) *problem.Details {
+			if err, ok := recovered.(error); ok && errors.Is(err, errNotFound) {
+				return problem.New("", "Not Found", http.StatusNotFound)
+			}
+
+			return nil
+		}))
+
+	tests := []struct {
+		Path     string
+		Status   int
+		Expected string
+	}{
+		{
+			Path:     "/not-found",
+			Status:   http.StatusNotFound,
+			Expected: `{"status": 404, "title": "Not Found"}`,
+		},
+		{
+			Path:     "/details",
+			Status:   http.StatusForbidden,
+			Expected: `{"status": 403, "title": "Forbidden"}`,
+		},
+		{
+			Path:     "/other",
+			Status:   http.StatusInternalServerError,
+			Expected: `{"status": 500, "title": "Internal Server Error"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, test.Path, nil)
+
+			handler.ServeHTTP(w, r)
+
+			assertResponse(t, w, test.Status, test.Expected)
+		})
+	}
+}
+
+func TestHandler_WithPanicHook(t *testing.T) {
+	type call struct {
+		path      string
+		recovered any
+		stack     string
+	}
+
+	var calls []call
+
+	handler := problem.Handler(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/details" {
+				panic(problem.New("", "Forbidden", http.StatusForbidden))
+			}
+
+			panic("something went wrong")
+		}),
+		problem.WithPanicHook(func(r *http.Request, recovered any, stack []byte) {
+			calls = append(calls, call{path: r.URL.Path, recovered: recovered, stack: string(stack)})
+
+			panic("hook failed")
+		}))
+
+	for _, path := range []string{"/details", "/other"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+
+		handler.ServeHTTP(w, r)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(calls))
+	}
+
+	if got, want := calls[0].path, "/details"; got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+
+	if _, ok := calls[0].recovered.(*problem.Details); !ok {
+		t.Errorf("got recovered value %#v, want *problem.Details", calls[0].recovered)
+	}
+
+	if got, want := calls[1].recovered, "something went wrong"; got != want {
+		t.Errorf("got recovered value %#v, want %#v", got, want)
+	}
+
+	for _, c := range calls {
+		if !strings.Contains(c.stack, "TestHandler_WithPanicHook") {
+			t.Errorf("stack for %q does not contain test function:\n%s", c.path, c.stack)
+		}
+	}
+}
+
+func TestHandlerFunc(t *testing.T) {
+	handler := problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		case "/details":
+			return fmt.Errorf("loading account: %w", problem.New("", "Forbidden", http.StatusForbidden))
+		default:
+			return errors.New("database connection lost")
+		}
+	})
+
+	t.Run("No error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/ok", nil)
+
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, http.StatusNoContent; got != want {
+			t.Errorf("got status %d, want %d", got, want)
+		}
+
+		if w.Body.Len() > 0 {
+			t.Errorf("got body %q, want none", w.Body.String())
+		}
+	})
+
+	t.Run("Details", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/details", nil)
+
+		handler.ServeHTTP(w, r)
+
+		assertResponse(t, w, http.StatusForbidden, `{
+			"status": 403,
+			"title": "Forbidden"
+		}`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/other", nil)
+
+		handler.ServeHTTP(w, r)
+
+		assertResponse(t, w, http.StatusInternalServerError, `{
+			"status": 500,
+			"title": "Internal Server Error"
+		}`)
+	})
+}
+
+func TestHandlerFunc_WithLogger(t *testing.T) {
+	var h recordHandler
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	err := errors.New("database connection lost")
+
+	problem.HandlerFunc(func(http.ResponseWriter, *http.Request) error {
+		return err
+	}, problem.WithLogger(slog.New(&h))).ServeHTTP(w, r)
+
+	if len(h.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(h.records))
+	}
+
+	attrs := make(map[string]slog.Value)
+
+	h.records[0].Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+
+	if got := attrs["error"].Any(); got != err {
+		t.Errorf("got error %v, want %v", got, err)
+	}
+
+	if _, ok := attrs["stack"]; ok {
+		t.Error("got stack attribute for returned error")
+	}
+}
+
+func TestInternal(t *testing.T) {
+	err := errors.New("database connection lost")
+
+	d := problem.Internal(err)
+
+	if !errors.Is(d, err) {
+		t.Errorf("errors.Is() returned false, want true")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	d.ServeHTTP(w, r)
+
+	assertResponse(t, w, http.StatusInternalServerError, `{
+		"status": 500,
+		"title": "Internal Server Error",
+		"detail": "An unexpected error occurred while processing the request."
+	}`)
+}
@@ -0,0 +1,71 @@
+package problem
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Log logs d using the given logger and level.
+//
+// The title of d is used as message. The type, status, title, detail and instance are added as attributes, as well
+// as all extensions, which are grouped under "extensions" and sorted by key.
+//
+// If d has an underlying error, the error is added as attribute named "error". The attribute value is the error
+// itself, so handlers can inspect it using functions like [errors.Is] and [errors.As].
+func (d *Details) Log(logger *slog.Logger, level slog.Level) {
+	logger.LogAttrs(context.Background(), level, d.Title, d.logAttrs()...)
+}
+
+var _ slog.LogValuer = (*Details)(nil)
+
+// LogValue implements the [slog.LogValuer] interface.
+//
+// The returned value is a group containing the same attributes as logged by [Details.Log], except that the
+// underlying error, if any, is logged using its Error method.
+func (d *Details) LogValue() slog.Value {
+	attrs := d.logAttrs()
+
+	if d.Underlying != nil {
+		// The error is always the last attribute.
+		attrs[len(attrs)-1] = slog.String("error", d.Underlying.Error())
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// logAttrs returns the attributes used for logging d. If d has an underlying error, it is always the last attribute.
+func (d *Details) logAttrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("type", cmp.Or(d.Type, AboutBlankTypeURI)),
+		slog.Int("status", d.Status),
+		slog.String("title", d.Title),
+	}
+
+	if d.Detail != "" {
+		attrs = append(attrs, slog.String("detail", d.Detail))
+	}
+
+	if d.Instance != "" {
+		attrs = append(attrs, slog.String("instance", d.Instance))
+	}
+
+	if len(d.Extensions) > 0 {
+		extensions := make([]any, 0, len(d.Extensions))
+
+		// Sort the keys so that the output is deterministic, same as when marshaling.
+		for _, k := range slices.Sorted(maps.Keys(d.Extensions)) {
+			extensions = append(extensions, slog.Any(k, d.Extensions[k]))
+		}
+
+		attrs = append(attrs, slog.Group("extensions", extensions...))
+	}
+
+	if d.Underlying != nil {
+		attrs = append(attrs, slog.Any("error", d.Underlying))
+	}
+
+	return attrs
+}
@@ -0,0 +1,156 @@
+package problem_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/nussjustin/problem"
+)
+
+type recordHandler struct {
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func TestDetails_Log(t *testing.T) {
+	var h recordHandler
+
+	underlying := errors.New("insufficient funds")
+
+	problem.New(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden,
+		problem.WithDetail("Your current balance is 30, but that costs 50."),
+		problem.WithExtension("balance", 30),
+		problem.WithUnderlying(underlying),
+	).Log(slog.New(&h), slog.LevelWarn)
+
+	if len(h.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(h.records))
+	}
+
+	r := h.records[0]
+
+	if got, want := r.Level, slog.LevelWarn; got != want {
+		t.Errorf("got level %s, want %s", got, want)
+	}
+
+	if got, want := r.Message, "You do not have enough credit."; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+
+	got := make(map[string]slog.Value)
+
+	r.Attrs(func(a slog.Attr) bool {
+		got[a.Key] = a.Value
+		return true
+	})
+
+	want := map[string]string{
+		"type":       "https://example.com/probs/out-of-credit",
+		"status":     "403",
+		"title":      "You do not have enough credit.",
+		"detail":     "Your current balance is 30, but that costs 50.",
+		"extensions": "[balance=30]",
+		"error":      "insufficient funds",
+	}
+
+	for k, v := range want {
+		if got[k].String() != v {
+			t.Errorf("got attribute %s=%q, want %q", k, got[k].String(), v)
+		}
+	}
+
+	if err, _ := got["error"].Any().(error); !errors.Is(err, underlying) {
+		t.Errorf("got error attribute %v, want %v", err, underlying)
+	}
+}
+
+func TestDetails_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	d := problem.New(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden,
+		problem.WithDetail("Your current balance is 30, but that costs 50."),
+		problem.WithInstance("/account/12345/msgs/abc"),
+		problem.WithExtension("balance", 30),
+		problem.WithUnderlying(errors.New("insufficient funds")),
+	)
+
+	logger.Info("request failed", "problem", d)
+
+	assertJSON(t, `{
+		"level": "INFO",
+		"msg": "request failed",
+		"problem": {
+			"type": "https://example.com/probs/out-of-credit",
+			"status": 403,
+			"title": "You do not have enough credit.",
+			"detail": "Your current balance is 30, but that costs 50.",
+			"instance": "/account/12345/msgs/abc",
+			"extensions": {"balance": 30},
+			"error": "insufficient funds"
+		}
+	}`, buf.Bytes())
+}
+
+func TestDetails_LogValue_ExtensionOrder(t *testing.T) {
+	d := problem.New("", "Forbidden", http.StatusForbidden,
+		problem.WithExtension("currency", "EUR"),
+		problem.WithExtension("balance", 30),
+		problem.WithExtension("accounts", 2),
+		problem.WithExtension("cost", 50))
+
+	for range 10 {
+		var buf bytes.Buffer
+
+		slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})).Info("", "problem", d)
+
+		const want = "problem.type=about:blank problem.status=403 problem.title=Forbidden " +
+			"problem.extensions.accounts=2 problem.extensions.balance=30 problem.extensions.cost=50 " +
+			"problem.extensions.currency=EUR\n"
+
+		if got := buf.String(); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
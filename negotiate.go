@@ -0,0 +1,34 @@
+package problem
+
+import (
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// acceptQuality returns the highest quality value given to any of the media types in the given Accept header value.
+//
+// Wildcards are not matched. If none of the media types is listed, -1 is returned.
+func acceptQuality(accept string, mediaTypes ...string) float64 {
+	best := -1.0
+
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || !slices.Contains(mediaTypes, mediaType) {
+			continue
+		}
+
+		q := 1.0
+
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+
+		best = max(best, q)
+	}
+
+	return best
+}
@@ -0,0 +1,40 @@
+package problem
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+)
+
+// FromNetError returns a new [Details] describing the given transport error or nil if the error is not recognized.
+//
+// This can be used by gateways and proxies to translate failures when talking to an upstream server into problems.
+// The following errors are recognized:
+//
+//   - Timeouts, as reported by [net.Error] or [os.ErrDeadlineExceeded], result in a "504 Gateway Timeout".
+//   - Refused connections ([syscall.ECONNREFUSED]) result in a "502 Bad Gateway".
+//   - DNS errors ([*net.DNSError]) result in a "502 Bad Gateway".
+//
+// The returned value has no type and uses [http.StatusText] as title. The error is set as Underlying error and not
+// exposed to clients.
+func FromNetError(err error) *Details {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return newNetErrorDetails(http.StatusGatewayTimeout, err)
+	case errors.Is(err, syscall.ECONNREFUSED), errors.As(err, &dnsErr):
+		return newNetErrorDetails(http.StatusBadGateway, err)
+	default:
+		return nil
+	}
+}
+
+func newNetErrorDetails(status int, err error) *Details {
+	return New("", "", status, WithTitleFromStatus(), WithUnderlying(err))
+}
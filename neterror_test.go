@@ -0,0 +1,89 @@
+package problem_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestFromNetError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	dns := &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}
+
+	tests := []struct {
+		Name  string
+		Error error
+		Want  *problem.Details
+	}{
+		{
+			Name:  "Nil",
+			Error: nil,
+			Want:  nil,
+		},
+		{
+			Name:  "Unrecognized",
+			Error: errors.New("something went wrong"),
+			Want:  nil,
+		},
+		{
+			Name:  "Timeout",
+			Error: fmt.Errorf("request failed: %w", timeoutError{}),
+			Want: &problem.Details{
+				Status: http.StatusGatewayTimeout,
+				Title:  "Gateway Timeout",
+			},
+		},
+		{
+			Name:  "Deadline exceeded",
+			Error: os.ErrDeadlineExceeded,
+			Want: &problem.Details{
+				Status: http.StatusGatewayTimeout,
+				Title:  "Gateway Timeout",
+			},
+		},
+		{
+			Name:  "Connection refused",
+			Error: refused,
+			Want: &problem.Details{
+				Status: http.StatusBadGateway,
+				Title:  "Bad Gateway",
+			},
+		},
+		{
+			Name:  "DNS",
+			Error: dns,
+			Want: &problem.Details{
+				Status: http.StatusBadGateway,
+				Title:  "Bad Gateway",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := problem.FromNetError(test.Error)
+
+			if test.Want != nil {
+				test.Want.Underlying = test.Error
+			}
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported, equateUnderlying); diff != "" {
+				t.Errorf("FromNetError() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package problem_test
+
+// raceEnabled is true if the race detector is enabled. See race_test.go.
+const raceEnabled = false
@@ -0,0 +1,112 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-json-experiment/json"
+)
+
+const (
+	// OAuth2ErrorExtension is the name of the extension used to store the OAuth 2.0 error code.
+	//
+	// See [OAuth2Error] and [ParseOAuth2Error].
+	OAuth2ErrorExtension = "error"
+
+	// OAuth2ErrorDescriptionExtension is the name of the extension used to store the OAuth 2.0 error description.
+	//
+	// See [OAuth2Error] and [ParseOAuth2Error].
+	OAuth2ErrorDescriptionExtension = "error_description"
+
+	// OAuth2ErrorURIExtension is the name of the extension used to store the OAuth 2.0 error URI.
+	//
+	// See [ParseOAuth2Error].
+	OAuth2ErrorURIExtension = "error_uri"
+)
+
+// oauth2Statuses maps OAuth 2.0 error codes to the HTTP status used for them.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc6749#section-5.2 and
+// https://datatracker.ietf.org/doc/html/rfc6750#section-3.1
+var oauth2Statuses = map[string]int{
+	"access_denied":             http.StatusForbidden,
+	"insufficient_scope":        http.StatusForbidden,
+	"invalid_client":            http.StatusUnauthorized,
+	"invalid_grant":             http.StatusBadRequest,
+	"invalid_request":           http.StatusBadRequest,
+	"invalid_scope":             http.StatusBadRequest,
+	"invalid_token":             http.StatusUnauthorized,
+	"server_error":              http.StatusInternalServerError,
+	"temporarily_unavailable":   http.StatusServiceUnavailable,
+	"unauthorized_client":       http.StatusBadRequest,
+	"unsupported_grant_type":    http.StatusBadRequest,
+	"unsupported_response_type": http.StatusBadRequest,
+}
+
+// OAuth2Status returns the HTTP status used for the given OAuth 2.0 error code.
+//
+// Unknown codes result in a "400 Bad Request", which is the default for OAuth 2.0 error responses.
+func OAuth2Status(code string) int {
+	if status, ok := oauth2Statuses[code]; ok {
+		return status
+	}
+
+	return http.StatusBadRequest
+}
+
+// OAuth2Error returns a new [Details] for an OAuth 2.0 error response with the given error code and description.
+//
+// The status is derived from the code using [OAuth2Status] and [http.StatusText] is used as title. The code and
+// description are stored in the [OAuth2ErrorExtension] and [OAuth2ErrorDescriptionExtension], so that clients that
+// only understand OAuth 2.0 error responses can still handle the problem. The description is also used as Detail.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+func OAuth2Error(code, description string) *Details {
+	status := OAuth2Status(code)
+
+	d := New("", http.StatusText(status), status, WithExtension(OAuth2ErrorExtension, code))
+
+	if description != "" {
+		d.Detail = description
+		d.Extensions[OAuth2ErrorDescriptionExtension] = description
+	}
+
+	return d
+}
+
+// ParseOAuth2Error parses the given body as OAuth 2.0 error response and returns a new [Details] for it.
+//
+// The result is the same as when calling [OAuth2Error] with the parsed error code and description. If the response
+// also contains an error URI, it is stored in the [OAuth2ErrorURIExtension]. Other members are ignored.
+//
+// If status is not 0, it is used instead of the status derived from the error code.
+//
+// An error is returned if the body is not a JSON object or does not contain an error code.
+func ParseOAuth2Error(body []byte, status int) (*Details, error) {
+	var resp struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		ErrorURI         string `json:"error_uri"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error == "" {
+		return nil, errors.New("problem: missing OAuth 2.0 error code")
+	}
+
+	d := OAuth2Error(resp.Error, resp.ErrorDescription)
+
+	if status != 0 {
+		d.Status = status
+		d.Title = http.StatusText(status)
+	}
+
+	if resp.ErrorURI != "" {
+		d.Extensions[OAuth2ErrorURIExtension] = resp.ErrorURI
+	}
+
+	return d, nil
+}
@@ -0,0 +1,115 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestOAuth2Error(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Code        string
+		Description string
+		Expected    string
+	}{
+		{
+			Name:        "Invalid token",
+			Code:        "invalid_token",
+			Description: "The access token expired",
+			Expected: `{
+				"status": 401,
+				"title": "Unauthorized",
+				"detail": "The access token expired",
+				"error": "invalid_token",
+				"error_description": "The access token expired"
+			}`,
+		},
+		{
+			Name:        "Insufficient scope",
+			Code:        "insufficient_scope",
+			Description: "The request requires the scope \"write\"",
+			Expected: `{
+				"status": 403,
+				"title": "Forbidden",
+				"detail": "The request requires the scope \"write\"",
+				"error": "insufficient_scope",
+				"error_description": "The request requires the scope \"write\""
+			}`,
+		},
+		{
+			Name: "Unknown code without description",
+			Code: "slow_down",
+			Expected: `{
+				"status": 400,
+				"title": "Bad Request",
+				"error": "slow_down"
+			}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := problem.OAuth2Error(test.Code, test.Description)
+
+			b, err := json.Marshal(d)
+			if err != nil {
+				t.Fatalf("failed to marshal details: %s", err)
+			}
+
+			assertJSON(t, test.Expected, b)
+
+			got, err := problem.ParseOAuth2Error(b, 0)
+			if err != nil {
+				t.Fatalf("failed to parse OAuth 2.0 error: %s", err)
+			}
+
+			if diff := cmp.Diff(d, got, ignoreUnexported); diff != "" {
+				t.Errorf("problem.ParseOAuth2Error() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseOAuth2Error(t *testing.T) {
+	t.Run("With status and URI", func(t *testing.T) {
+		got, err := problem.ParseOAuth2Error([]byte(`{
+			"error": "invalid_grant",
+			"error_description": "The authorization code expired",
+			"error_uri": "https://example.com/docs/errors#invalid_grant"
+		}`), http.StatusUnauthorized)
+		if err != nil {
+			t.Fatalf("failed to parse OAuth 2.0 error: %s", err)
+		}
+
+		want := &problem.Details{
+			Status: http.StatusUnauthorized,
+			Title:  "Unauthorized",
+			Detail: "The authorization code expired",
+			Extensions: map[string]any{
+				"error":             "invalid_grant",
+				"error_description": "The authorization code expired",
+				"error_uri":         "https://example.com/docs/errors#invalid_grant",
+			},
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+			t.Errorf("problem.ParseOAuth2Error() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	for name, body := range map[string]string{
+		"Invalid JSON":  `{`,
+		"Missing error": `{"error_description": "Something went wrong"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := problem.ParseOAuth2Error([]byte(body), 0); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
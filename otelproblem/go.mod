@@ -0,0 +1,25 @@
+module github.com/nussjustin/problem/otelproblem
+
+go 1.24
+
+require (
+	github.com/google/go-cmp v0.7.0
+	github.com/nussjustin/problem v0.1.0
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/sdk v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require (
+	github.com/go-json-experiment/json v0.0.0-20250813233538-9b1f9ea2e11b // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+)
+
+// Build against the local copy of the core module during development. Consumers of this module are not affected by
+// this directive and use the version required above.
+replace github.com/nussjustin/problem => ../
@@ -0,0 +1,55 @@
+// Package otelproblem implements recording of RFC 9457 problem details on OpenTelemetry spans.
+//
+// This package lives in its own module so that the core problem module does not depend on OpenTelemetry.
+package otelproblem
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nussjustin/problem"
+)
+
+const (
+	// TypeKey is the attribute key used for the problem type URI.
+	TypeKey = attribute.Key("problem.type")
+
+	// StatusKey is the attribute key used for the problem status.
+	StatusKey = attribute.Key("problem.status")
+
+	// TitleKey is the attribute key used for the problem title.
+	TitleKey = attribute.Key("problem.title")
+)
+
+// Record records the given problem on the span stored in ctx, if any.
+//
+// The type, status and title of the problem are added as attributes using [TypeKey], [StatusKey] and [TitleKey] and
+// the span status is set to [codes.Error] with the title as description.
+//
+// If the span is not recording, Record does nothing.
+func Record(ctx context.Context, d *problem.Details) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(
+		TypeKey.String(cmp.Or(d.Type, problem.AboutBlankTypeURI)),
+		StatusKey.Int(d.Status),
+		TitleKey.String(d.Title),
+	)
+
+	span.SetStatus(codes.Error, d.Title)
+}
+
+// Serve records the problem on the span of the request context using [Record] and then serves it using
+// [problem.Serve].
+func Serve(w http.ResponseWriter, r *http.Request, d *problem.Details, opts ...problem.EncodeOption) {
+	Record(r.Context(), d)
+	problem.Serve(w, r, d, opts...)
+}
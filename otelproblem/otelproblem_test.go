@@ -0,0 +1,71 @@
+package otelproblem_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/nussjustin/problem"
+	"github.com/nussjustin/problem/otelproblem"
+)
+
+func TestServe(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+
+	r := httptest.NewRequest(http.MethodGet, "/account/12345/msgs/abc", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	otelproblem.Serve(w, r, &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	})
+
+	span.End()
+
+	if got, want := w.Code, http.StatusForbidden; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	got := make(map[string]string)
+
+	for _, kv := range spans[0].Attributes() {
+		got[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	want := map[string]string{
+		"problem.type":   "https://example.com/probs/out-of-credit",
+		"problem.status": "403",
+		"problem.title":  "You do not have enough credit.",
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("span attributes mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, want := spans[0].Status(), (sdktrace.Status{
+		Code:        codes.Error,
+		Description: "You do not have enough credit.",
+	}); got != want {
+		t.Errorf("got span status %v, want %v", got, want)
+	}
+}
+
+func TestRecord_NoSpan(t *testing.T) {
+	// Must not panic without a span in the context.
+	otelproblem.Record(context.Background(), &problem.Details{Status: http.StatusForbidden})
+}
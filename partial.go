@@ -0,0 +1,65 @@
+package problem
+
+import "fmt"
+
+const (
+	// PartialResultsExtension is the name of the extension used to store [PartialResults].
+	//
+	// See [WithPartialResults] and [Details.PartialResults].
+	PartialResultsExtension = "partial"
+)
+
+// PartialResults describes the outcome of a bulk operation that only succeeded partially.
+type PartialResults struct {
+	// Total is the total number of items processed by the operation.
+	Total int `json:"total"`
+
+	// Succeeded is the number of items that were processed successfully.
+	Succeeded int `json:"succeeded"`
+
+	// Failed is the number of items that could not be processed.
+	Failed int `json:"failed"`
+}
+
+// WithPartialResults sets the [PartialResultsExtension] for a new Details value.
+//
+// WithPartialResults panics if any of the counts is negative or if succeeded and failed add up to more than total.
+func WithPartialResults(total, succeeded, failed int) Option {
+	if total < 0 || succeeded < 0 || failed < 0 {
+		panic(fmt.Sprintf("problem: negative partial results count (total=%d, succeeded=%d, failed=%d)",
+			total, succeeded, failed))
+	}
+
+	if succeeded+failed > total {
+		panic(fmt.Sprintf("problem: partial results exceed total (total=%d, succeeded=%d, failed=%d)",
+			total, succeeded, failed))
+	}
+
+	return WithExtension(PartialResultsExtension, PartialResults{
+		Total:     total,
+		Succeeded: succeeded,
+		Failed:    failed,
+	})
+}
+
+// PartialResults returns the partial results set via [WithPartialResults] or parsed from JSON, if any.
+//
+// If the extension is not set or has an invalid format, the second return value is false.
+func (d *Details) PartialResults() (PartialResults, bool) {
+	switch v := d.Extensions[PartialResultsExtension].(type) {
+	case PartialResults:
+		return v, true
+	case map[string]any:
+		total, ok1 := intValue(v["total"])
+		succeeded, ok2 := intValue(v["succeeded"])
+		failed, ok3 := intValue(v["failed"])
+
+		if !ok1 || !ok2 || !ok3 {
+			return PartialResults{}, false
+		}
+
+		return PartialResults{Total: total, Succeeded: succeeded, Failed: failed}, true
+	default:
+		return PartialResults{}, false
+	}
+}
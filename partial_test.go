@@ -0,0 +1,86 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestWithPartialResults(t *testing.T) {
+	d := problem.New(
+		"https://example.com/probs/partial-import",
+		"Some items could not be imported.",
+		http.StatusMultiStatus,
+		problem.WithPartialResults(10, 7, 3))
+
+	want := problem.PartialResults{Total: 10, Succeeded: 7, Failed: 3}
+
+	if got, ok := d.PartialResults(); !ok || got != want {
+		t.Errorf("got %v, %t, want %v, true", got, ok, want)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"type": "https://example.com/probs/partial-import",
+		"title": "Some items could not be imported.",
+		"status": 207,
+		"partial": {"total": 10, "succeeded": 7, "failed": 3}
+	}`, b)
+
+	var parsed problem.Details
+
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		t.Fatalf("failed to unmarshal details: %s", err)
+	}
+
+	if got, ok := parsed.PartialResults(); !ok || got != want {
+		t.Errorf("got %v, %t after round-trip, want %v, true", got, ok, want)
+	}
+
+	if _, ok := (&problem.Details{}).PartialResults(); ok {
+		t.Errorf("got partial results for empty details")
+	}
+
+	for _, body := range []string{
+		`{"partial": {"total": 1.5, "succeeded": 1, "failed": 0}}`,
+		`{"partial": {"total": 1e300, "succeeded": 1, "failed": 0}}`,
+	} {
+		if err := json.Unmarshal([]byte(body), &parsed); err != nil {
+			t.Fatalf("failed to unmarshal details: %s", err)
+		}
+
+		if got, ok := parsed.PartialResults(); ok {
+			t.Errorf("got %v, true for %s, want false", got, body)
+		}
+	}
+}
+
+func TestWithPartialResults_Invalid(t *testing.T) {
+	tests := []struct {
+		Name                     string
+		Total, Succeeded, Failed int
+	}{
+		{Name: "Negative total", Total: -1},
+		{Name: "Negative succeeded", Total: 1, Succeeded: -1},
+		{Name: "Negative failed", Total: 1, Failed: -1},
+		{Name: "Exceeds total", Total: 2, Succeeded: 2, Failed: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic was raised")
+				}
+			}()
+
+			problem.WithPartialResults(test.Total, test.Succeeded, test.Failed)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package problem
+
+import (
+	"bytes"
+	"sync"
+)
+
+var detailsPool = sync.Pool{
+	New: func() any {
+		return new(Details)
+	},
+}
+
+// maxPooledBufferSize is the maximum capacity of buffers that are returned to bufferPool. Larger buffers are
+// dropped, so that a single large problem does not keep a large amount of memory alive.
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool contains buffers used by [Serve] for encoding problems.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// NewPooled is like [New] but reuses a previously released [Details] value if possible.
+//
+// Values returned by NewPooled can be returned to the pool using [Release] once they are not needed anymore.
+//
+// This is only useful for code that creates a large number of short-lived problems, for example in response to
+// invalid requests. Most users should use [New] or [Type.Details] instead.
+func NewPooled(typ string, title string, status int, opts ...Option) *Details {
+	d := detailsPool.Get().(*Details)
+	d.Type = typ
+	d.Status = status
+	d.Title = title
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Release resets d and returns it to the pool used by [NewPooled].
+//
+// All fields are reset to their zero value, except for Extensions, which is cleared, but kept for reuse.
+//
+// After calling Release, the caller must not use d or any reference to it anymore, including the Extensions map.
+// In particular, d must not be released while it is still in use, for example as error wrapped by another error
+// or while it is being served.
+func Release(d *Details) {
+	extensions := d.Extensions
+	clear(extensions)
+
+	*d = Details{Extensions: extensions}
+
+	detailsPool.Put(d)
+}
@@ -0,0 +1,144 @@
+package problem_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestRelease(t *testing.T) {
+	d := problem.NewPooled(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden,
+		problem.WithDetail("Your current balance is 30, but that costs 50."),
+		problem.WithInstance("/account/12345/msgs/abc"),
+		problem.WithExtension("balance", 30),
+		problem.WithUnderlying(testError{}),
+	)
+
+	extensions := d.Extensions
+
+	problem.Release(d)
+
+	if diff := cmp.Diff(&problem.Details{Extensions: map[string]any{}}, d, ignoreUnexported); diff != "" {
+		t.Errorf("Release() mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(extensions) != 0 {
+		t.Errorf("got %d extensions after Release(), want 0", len(extensions))
+	}
+}
+
+func BenchmarkNewPooled(b *testing.B) {
+	b.ReportAllocs()
+
+	for b.Loop() {
+		d := problem.NewPooled(
+			"https://example.com/probs/out-of-credit",
+			"You do not have enough credit.",
+			http.StatusForbidden,
+			problem.WithExtension("balance", 30),
+		)
+
+		problem.Release(d)
+	}
+}
+
+// serveBenchmarks are the problems used by [BenchmarkServe] and [TestServe_Allocs].
+//
+// BaselineAllocs is the number of allocations done when serving the problem before [problem.Serve] was added, when
+// problems were served using [problem.Details.ServeHTTP] and marshaled without options. Serving a problem must not
+// allocate more than that, with the exception of one allocation for sorting the extensions.
+var serveBenchmarks = []struct {
+	Name           string
+	Details        *problem.Details
+	BaselineAllocs float64
+}{
+	{
+		Name:           "Minimal",
+		Details:        &problem.Details{Status: http.StatusForbidden},
+		BaselineAllocs: 3,
+	},
+	{
+		Name: "Full",
+		Details: problem.New(
+			"https://example.com/probs/out-of-credit",
+			"You do not have enough credit.",
+			http.StatusForbidden,
+			problem.WithDetail("Your current balance is 30, but that costs 50."),
+			problem.WithInstance("/account/12345/msgs/abc"),
+			problem.WithExtension("balance", 30),
+			problem.WithExtension("accounts", []string{"/account/12345", "/account/67890"}),
+		),
+		BaselineAllocs: 5,
+	},
+}
+
+func TestServe_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable with the race detector enabled")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	for _, bm := range serveBenchmarks {
+		t.Run(bm.Name, func(t *testing.T) {
+			w := &discardResponseWriter{header: make(http.Header)}
+
+			allocs := testing.AllocsPerRun(100, func() {
+				clear(w.header)
+				problem.Serve(w, r, bm.Details)
+			})
+
+			// Allow one more allocation if there are extensions, since these are sorted before being written.
+			limit := bm.BaselineAllocs
+			if len(bm.Details.Extensions) > 0 {
+				limit++
+			}
+
+			if allocs > limit {
+				t.Errorf("got %v allocations, want at most %v (baseline %v)", allocs, limit, bm.BaselineAllocs)
+			}
+		})
+	}
+}
+
+func BenchmarkServe(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	for _, bm := range serveBenchmarks {
+		b.Run(bm.Name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			w := &discardResponseWriter{header: make(http.Header)}
+
+			for b.Loop() {
+				clear(w.header)
+				problem.Serve(w, r, bm.Details)
+			}
+
+			b.ReportMetric(bm.BaselineAllocs, "baseline-allocs/op")
+		})
+	}
+}
+
+// discardResponseWriter is a minimal [http.ResponseWriter] that discards the response, so that benchmarks only
+// measure allocations done by the package.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
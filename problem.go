@@ -6,10 +6,23 @@ package problem
 
 import (
 	"cmp"
+	"context"
+	stdjson "encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"maps"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-json-experiment/json"
 	"github.com/go-json-experiment/json/jsontext"
@@ -22,6 +35,13 @@ const (
 	AboutBlankTypeURI = "about:blank"
 )
 
+const (
+	// CorrelationIDExtension is the name of the extension used to store the correlation ID of a problem.
+	//
+	// See [WithCorrelationID] and [Details.CorrelationID].
+	CorrelationIDExtension = "correlation_id"
+)
+
 const (
 	// ContentType is the media type used for problem responses, as defined by IANA.
 	//
@@ -29,6 +49,113 @@ const (
 	ContentType = "application/problem+json"
 )
 
+// DefaultMaxBodySize is the maximum number of bytes read from the response body by [From] and [FromContext], unless
+// a different limit is set using [WithMaxBodySize].
+//
+// DefaultMaxBodySize must not be modified while problems are being parsed.
+var DefaultMaxBodySize int64 = 1 << 20
+
+// ErrBodyTooLarge is returned by [From] and [FromContext] if the response body exceeds the maximum body size.
+//
+// See also [DefaultMaxBodySize] and [WithMaxBodySize].
+var ErrBodyTooLarge = errors.New("problem: response body too large")
+
+// DefaultTypeURI, if set, is used as type URI in the generated JSON for problems with an empty [Details.Type].
+//
+// By default, the type is omitted for such problems, which is equivalent to "about:blank". Setting DefaultTypeURI
+// can be used to, for example, point all untyped problems to a generic documentation page.
+//
+// Like [TypeURIRewriter], DefaultTypeURI only affects generated JSON and does not affect [Is].
+//
+// DefaultTypeURI must not be modified while problems are being marshaled.
+var DefaultTypeURI string
+
+// TypeURIRewriter, if set, is called with the non-empty [Details.Type] (or [DefaultTypeURI]) of each problem when
+// marshaling and the returned value is used as type URI in the generated JSON instead.
+//
+// This can be used to, for example, prefix all type URIs with an environment-specific base URI.
+//
+// The rewriter is only applied to generated JSON and does not modify the [Details] itself. In particular [Is] still
+// compares against the original, unmodified value. Problems parsed from JSON, for example via [From], contain the
+// rewritten URI and may need to be matched against types with correspondingly rewritten URIs.
+//
+// TypeURIRewriter must not be modified while problems are being marshaled.
+var TypeURIRewriter func(uri string) string
+
+// ExposeDetail enables using the error message of [Details.Underlying] as detail when marshaling a problem without
+// an explicit Detail.
+//
+// This can be useful during development, but should never be enabled in production, since error messages may
+// contain sensitive information. See also [WithExposedDetail] for enabling this only for specific calls.
+//
+// ExposeDetail must not be modified while problems are being marshaled.
+var ExposeDetail bool
+
+// StrictExtensions causes [WithExtension], [WithExtensions], [WithExtensionsIf] and [WithKV] to panic when used
+// with one of the [ReservedMembers] as key.
+//
+// By default, such extensions are accepted, but handled according to [ExtensionCollisionPolicy] when marshaling.
+// Enabling StrictExtensions, for example in tests, makes these mistakes easier to find. See also [Details.Validate].
+//
+// StrictExtensions must not be modified while problems are being created.
+var StrictExtensions bool
+
+// CollisionPolicy defines how extensions that collide with one of the [ReservedMembers] are handled when marshaling.
+//
+// See also [ExtensionCollisionPolicy].
+type CollisionPolicy int
+
+const (
+	// CollisionDrop causes colliding extensions to be silently dropped. This is the default.
+	CollisionDrop CollisionPolicy = iota
+
+	// CollisionPrefix causes colliding extensions to be renamed by prefixing the key with [CollisionPrefixString],
+	// for example "status" becomes "ext_status".
+	//
+	// If another extension already uses the prefixed key, for example "ext_status", that extension takes precedence
+	// and the colliding extension is dropped.
+	CollisionPrefix
+
+	// CollisionError causes marshaling to fail with an error for colliding extensions.
+	CollisionError
+)
+
+// CollisionPrefixString is the prefix added to extension keys by [CollisionPrefix].
+const CollisionPrefixString = "ext_"
+
+// ExtensionCollisionPolicy defines how extensions using one of the [ReservedMembers] as key are handled when
+// marshaling a [Details] value.
+//
+// ExtensionCollisionPolicy must not be modified while problems are being marshaled.
+var ExtensionCollisionPolicy = CollisionDrop
+
+// OnMarshalError, if set, is called by [Serve] when d could not be marshaled.
+//
+// If OnMarshalError returns a non-nil value, the returned value is served instead of d. Otherwise, or if the
+// replacement can also not be marshaled, Serve panics as usual.
+//
+// OnMarshalError must not be modified while problems are being served.
+var OnMarshalError func(d *Details, err error) *Details
+
+// reservedMembers contains the names of all members defined by RFC 9457.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9457#name-members-of-a-problem-detail
+var reservedMembers = []string{"type", "status", "title", "detail", "instance"}
+
+// ReservedMembers returns the names of all members defined by RFC 9457.
+//
+// Extensions using any of these names are handled according to [ExtensionCollisionPolicy] when marshaling a
+// [Details] value.
+//
+// The returned slice is a copy and can be modified freely.
+func ReservedMembers() []string {
+	return slices.Clone(reservedMembers)
+}
+
+func isReservedMember(name string) bool {
+	return slices.Contains(reservedMembers, name)
+}
+
 // Details defines an RFC 9457 problem details object.
 //
 // Details also implements the [error] interface and can optionally wrap an existing [error] value.
@@ -73,12 +200,41 @@ type Details struct {
 	//
 	// This field is not part of RFC 9457 and is neither included in generated JSON nor populated during unmarshaling.
 	Underlying error
+
+	// Header optionally contains additional HTTP headers that are set by [Serve] when serving the problem.
+	//
+	// This field is not part of RFC 9457 and is not included in generated JSON.
+	Header http.Header
+
+	// Trailer optionally contains HTTP trailers that are announced and sent by [Serve] after the body.
+	//
+	// This field is not part of RFC 9457 and is not included in generated JSON.
+	Trailer http.Header
+
+	// IgnoredMembers optionally contains members defined by RFC 9457 that were ignored when parsing a problem using
+	// [From] with [WithIgnoredMembers], because their value had the wrong type.
+	//
+	// This field is not part of RFC 9457 and is not included in generated JSON.
+	IgnoredMembers map[string]any
+
+	// meta contains internal metadata set via [WithMeta]. It is never serialized.
+	meta map[string]any
 }
 
 // Option defines functional options that can be used to fill in optional values when creating a [Details] via
 // [New] or via [Type.Details].
 type Option func(*Details)
 
+// WithType sets the Type for a new Details value.
+//
+// This can be used to override the type URI when creating a problem via [Type.Details], for example for a more
+// specific sub-case of a type.
+func WithType(uri string) Option {
+	return func(d *Details) {
+		d.Type = uri
+	}
+}
+
 // WithStatus sets the Status for a new Details value.
 func WithStatus(status int) Option {
 	return func(d *Details) {
@@ -86,6 +242,40 @@ func WithStatus(status int) Option {
 	}
 }
 
+// WithStatusIfUnset sets the Status for a new Details value, but only if no status was set before.
+//
+// This can be used to set a default status without overriding a more specific status set by an earlier option or
+// by [Details.Apply].
+func WithStatusIfUnset(status int) Option {
+	return func(d *Details) {
+		if d.Status == 0 {
+			d.Status = status
+		}
+	}
+}
+
+// WithTitle sets the Title for a new Details value.
+//
+// This can be used to specialize the generic title of a [Type] when creating a problem via [Type.Details]. See also
+// [WithTitleFromStatus].
+func WithTitle(title string) Option {
+	return func(d *Details) {
+		d.Title = title
+	}
+}
+
+// WithTitleFromStatus sets the Title for a new Details value to the [http.StatusText] for its current Status.
+//
+// The status is read when the option is applied, so this must be used after any option that changes the status, for
+// example [WithStatus]. If there is no status text for the status, the Title is left unchanged.
+func WithTitleFromStatus() Option {
+	return func(d *Details) {
+		if title := http.StatusText(d.Status); title != "" {
+			d.Title = title
+		}
+	}
+}
+
 // WithDetail sets the Detail for a new Details value.
 func WithDetail(detail string) Option {
 	return func(d *Details) {
@@ -100,110 +290,1429 @@ func WithInstance(instance string) Option {
 	}
 }
 
-// WithExtension adds the given key-value pair to the Extensions of a new Details value.
-func WithExtension(key string, value any) Option {
-	return func(d *Details) {
-		if d.Extensions == nil {
-			d.Extensions = make(map[string]any)
+// WithExtension adds the given key-value pair to the Extensions of a new Details value.
+//
+// Extensions using one of the [ReservedMembers] as key are ignored when marshaling, unless configured otherwise using
+// [ExtensionCollisionPolicy]. See also [StrictExtensions].
+func WithExtension(key string, value any) Option {
+	return func(d *Details) {
+		checkExtensionKey(key)
+
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any)
+		}
+		d.Extensions[key] = value
+	}
+}
+
+// checkExtensionKey panics if [StrictExtensions] is enabled and key is one of the [ReservedMembers].
+func checkExtensionKey(key string) {
+	if StrictExtensions && isReservedMember(key) {
+		panic(fmt.Sprintf("problem: extension %q collides with reserved member", key))
+	}
+}
+
+// WithExtensions adds the values to the Extensions of a new Details value.
+func WithExtensions(extensions map[string]any) Option {
+	return func(d *Details) {
+		for key := range extensions {
+			checkExtensionKey(key)
+		}
+
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any, len(extensions))
+		}
+		maps.Copy(d.Extensions, extensions)
+	}
+}
+
+// WithKV adds the given alternating key-value pairs to the Extensions of a new Details value, similar to the
+// arguments used by [log/slog].
+//
+// Example:
+//
+//	problem.New(typ, title, status, problem.WithKV("balance", 30, "currency", "EUR"))
+//
+// WithKV panics if the number of arguments is odd or if any key is not a string.
+func WithKV(pairs ...any) Option {
+	if len(pairs)%2 != 0 {
+		panic(fmt.Sprintf("problem: odd number of key-value arguments (%d)", len(pairs)))
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		if _, ok := pairs[i].(string); !ok {
+			panic(fmt.Sprintf("problem: key at index %d is of type %T, not string", i, pairs[i]))
+		}
+	}
+
+	return func(d *Details) {
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any, len(pairs)/2)
+		}
+
+		for i := 0; i < len(pairs); i += 2 {
+			key := pairs[i].(string)
+			checkExtensionKey(key)
+			d.Extensions[key] = pairs[i+1]
+		}
+	}
+}
+
+// WithExtensionsIf is like [WithExtensions], but only adds the extensions if cond is true.
+//
+// This can be used to add extensions conditionally, for example debug information only in development.
+func WithExtensionsIf(cond bool, extensions map[string]any) Option {
+	return func(d *Details) {
+		if cond {
+			WithExtensions(extensions)(d)
+		}
+	}
+}
+
+// WithUnderlying sets the given value as the underlying error of a new Details value.
+func WithUnderlying(err error) Option {
+	return func(d *Details) {
+		d.Underlying = err
+	}
+}
+
+// WithMeta attaches the given value as internal metadata under key to a new Details value.
+//
+// Unlike extensions, metadata is never serialized and can be used to attach values like loggers or the original
+// request to a problem without risking leaking them to clients. Metadata can be retrieved using [Details.Meta].
+func WithMeta(key string, value any) Option {
+	return func(d *Details) {
+		if d.meta == nil {
+			d.meta = make(map[string]any)
+		}
+
+		d.meta[key] = value
+	}
+}
+
+// WithHeader adds the given header to the Header of a new Details value.
+func WithHeader(key, value string) Option {
+	return func(d *Details) {
+		if d.Header == nil {
+			d.Header = make(http.Header)
+		}
+		d.Header.Add(key, value)
+	}
+}
+
+// WithRetryAfter sets the Retry-After header of a new Details value to the given delay in seconds.
+//
+// Delays are rounded up to full seconds. Negative delays are treated as 0. The header is only sent with the response
+// and not included in the body. See also [WithRetryAfterTime] and [Details.RetryAfter].
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9110#name-retry-after
+func WithRetryAfter(delay time.Duration) Option {
+	seconds := int64(math.Ceil(max(delay, 0).Seconds()))
+
+	return func(d *Details) {
+		if d.Header == nil {
+			d.Header = make(http.Header)
+		}
+		d.Header.Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+}
+
+// WithRetryAfterTime sets the Retry-After header of a new Details value to the given time as HTTP-date.
+//
+// The header is only sent with the response and not included in the body. See also [WithRetryAfter] and
+// [Details.RetryAfter].
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9110#name-retry-after
+func WithRetryAfterTime(t time.Time) Option {
+	date := t.UTC().Format(http.TimeFormat)
+
+	return func(d *Details) {
+		if d.Header == nil {
+			d.Header = make(http.Header)
+		}
+		d.Header.Set("Retry-After", date)
+	}
+}
+
+// WithTrailer adds the given trailer to the Trailer of a new Details value.
+//
+// Trailers are sent after the body, which can be used for diagnostics that are only available after the response was
+// generated, like a Server-Timing summary. Trailers are ignored for responses that do not support them.
+func WithTrailer(key, value string) Option {
+	return func(d *Details) {
+		if d.Trailer == nil {
+			d.Trailer = make(http.Header)
+		}
+		d.Trailer.Add(key, value)
+	}
+}
+
+// WithLocation sets the Location header in the Header of a new Details value.
+//
+// This can be used to serve a problem as body of a redirect (3xx) response, for example to explain the reason for
+// the redirect.
+func WithLocation(url string) Option {
+	return func(d *Details) {
+		if d.Header == nil {
+			d.Header = make(http.Header)
+		}
+		d.Header.Set("Location", url)
+	}
+}
+
+// WithCorrelationID sets the correlation ID extension for a new Details value.
+//
+// Unlike [Details.Instance] the correlation ID is meant to be a machine-readable value, for example a trace ID, that
+// can be used to correlate a problem with logs or other diagnostics.
+//
+// See also [CorrelationIDExtension].
+func WithCorrelationID(id string) Option {
+	return WithExtension(CorrelationIDExtension, id)
+}
+
+// New returns a new Details instance using the given type, status and title.
+//
+// It is also possible to set the Detail and Instance fields as well as extensions by
+// providing one or more [Option] values.
+//
+// Most users should prefer creating a Details instance via a struct literal or using [Type.Details] instead.
+func New(typ string, title string, status int, opts ...Option) *Details {
+	p := &Details{
+		Type:   typ,
+		Status: status,
+		Title:  title,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// FromError returns a new Details instance for the given error, using the given type, status and title.
+//
+// The error is used as Underlying error and its message as Detail, unless overridden using [WithDetail]. Other options
+// are applied the same way as with [New].
+//
+// If err is or wraps a *Details, as reported by [errors.As], that *Details is returned instead and no options are
+// applied. If err is nil, FromError returns nil.
+func FromError(err error, typ string, title string, status int, opts ...Option) *Details {
+	if err == nil {
+		return nil
+	}
+
+	var d *Details
+	if errors.As(err, &d) {
+		return d
+	}
+
+	p := New(typ, title, status, WithDetail(err.Error()), WithUnderlying(err))
+	return p.Apply(opts...)
+}
+
+// FromOption defines functional options that can be used to customize the behaviour of [From].
+type FromOption func(*fromOptions)
+
+type fromOptions struct {
+	instanceBase       *url.URL
+	nestedExtensions   bool
+	capturedHeaders    []string
+	lenientContentType bool
+	maxBodySize        int64
+	ignoredMembers     bool
+	jsonNumbers        bool
+}
+
+// WithCapturedHeaders causes [From] to copy the given headers from the response into [Details.Header].
+//
+// Since [Serve] adds all headers from [Details.Header] to the response, this can be used by proxies to pass through
+// specific headers together with the problem.
+func WithCapturedHeaders(names ...string) FromOption {
+	return func(o *fromOptions) {
+		o.capturedHeaders = append(o.capturedHeaders, names...)
+	}
+}
+
+// FromNestedExtensions causes [From] to also accept extensions nested inside a single "extensions" object, as
+// generated when using [WithNestedExtensions].
+//
+// Nested extensions are merged with any top-level extensions, with nested values taking precedence.
+func FromNestedExtensions() FromOption {
+	return func(o *fromOptions) {
+		o.nestedExtensions = true
+	}
+}
+
+// WithLenientContentType causes [From] and [Parse] to also accept any application/*+json media type instead of only
+// application/problem+json, for example "application/vnd.acme.problem+json" as generated when using
+// [WithContentTypeSuffix].
+func WithLenientContentType() FromOption {
+	return func(o *fromOptions) {
+		o.lenientContentType = true
+	}
+}
+
+// WithMaxBodySize sets the maximum number of bytes read from the response body by [From], overriding
+// [DefaultMaxBodySize].
+//
+// If the body is larger, [ErrBodyTooLarge] is returned. A negative value disables the limit.
+func WithMaxBodySize(n int64) FromOption {
+	return func(o *fromOptions) {
+		o.maxBodySize = n
+	}
+}
+
+// WithIgnoredMembers causes [From] to store members defined by RFC 9457 that are ignored because their value has the
+// wrong type in [Details.IgnoredMembers], for example a "status" given as string.
+//
+// This can be used for diagnosing problems returned by misbehaving servers.
+func WithIgnoredMembers() FromOption {
+	return func(o *fromOptions) {
+		o.ignoredMembers = true
+	}
+}
+
+// WithJSONNumbers causes [From] to decode numbers in extensions as [encoding/json.Number] instead of float64.
+//
+// This preserves the exact value of numbers that can not be represented as float64, for example large integer IDs.
+// Values of type json.Number are encoded as numbers again when marshaling using [Marshal], [Serve] or
+// [Details.MarshalJSON], so that such problems can be passed through without losing precision. The status is still
+// decoded into [Details.Status] as usual.
+//
+// [ExtensionInt], [Details.PartialResults] and [Details.Normalize] accept json.Number values. See [JSONNumbers] for
+// decoding numbers as json.Number when unmarshaling a [Details] directly.
+func WithJSONNumbers() FromOption {
+	return func(o *fromOptions) {
+		o.jsonNumbers = true
+	}
+}
+
+// WithInstanceBase causes [From] to resolve a relative [Details.Instance] against the given base URL.
+//
+// Instances that are already absolute or can not be parsed as URI reference are left unchanged.
+func WithInstanceBase(base *url.URL) FromOption {
+	return func(o *fromOptions) {
+		o.instanceBase = base
+	}
+}
+
+// EncodeOption defines functional options that can be used to customize how a [Details] is encoded by [Marshal]
+// and [Serve].
+type EncodeOption func(*encodeOptions)
+
+type encodeOptions struct {
+	nestedExtensions bool
+	status0AsNull    bool
+	floatStatus      bool
+
+	nonFiniteFloatsAsNull bool
+	stdlibJSON            bool
+	memberOrder           []string
+	withoutInstance       bool
+	contentLength         bool
+	streaming             bool
+	exposeDetail          bool
+	sanitizeText          bool
+	statusFromTitle       map[string]int
+	contentType           string
+	xml                   bool
+	snakeCaseExtensions   bool
+
+	// err is returned when marshaling with these options, for example if an option was given an invalid value.
+	err error
+}
+
+func (o *encodeOptions) encodeValue(enc *jsontext.Encoder, v any) error {
+	if n, ok := v.(stdjson.Number); ok {
+		return marshalNumber(enc, n)
+	}
+
+	if !o.stdlibJSON {
+		return json.MarshalEncode(enc, v)
+	}
+
+	b, err := stdjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return enc.WriteValue(b)
+}
+
+// defaultEncodeOptions is returned by newEncodeOptions if no options are given, to avoid an allocation in the common
+// case. It must not be modified.
+var defaultEncodeOptions encodeOptions
+
+func newEncodeOptions(opts []EncodeOption) *encodeOptions {
+	if len(opts) == 0 {
+		return &defaultEncodeOptions
+	}
+
+	var o encodeOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &o
+}
+
+// WithNestedExtensions causes extensions to be written into a single "extensions" object instead of being added as
+// top-level members.
+//
+// Note that this deviates from RFC 9457, which defines extensions as top-level members of the problem details
+// object. Clients must explicitly opt in to parsing this format, for example using [FromNestedExtensions].
+func WithNestedExtensions() EncodeOption {
+	return func(o *encodeOptions) {
+		o.nestedExtensions = true
+	}
+}
+
+// WithStatus0AsNull causes a zero [Details.Status] to be encoded as "status": null instead of being omitted.
+//
+// This can be used for clients that require the "status" member to always be present.
+func WithStatus0AsNull() EncodeOption {
+	return func(o *encodeOptions) {
+		o.status0AsNull = true
+	}
+}
+
+// WithFloatStatus causes [Details.Status] to be encoded as a JSON number with a fractional part, for example
+// "status": 403.0 instead of "status": 403.
+//
+// This should only be used for clients that can not handle integer status codes. By default, the status is always
+// encoded as integer.
+func WithFloatStatus() EncodeOption {
+	return func(o *encodeOptions) {
+		o.floatStatus = true
+	}
+}
+
+// WithNonFiniteFloatsAsNull causes extensions with a NaN or infinite float value to be encoded as null.
+//
+// By default, marshaling fails with an error naming the extension if an extension has such a value, since these can
+// not be represented in JSON.
+//
+// Only direct extension values of type float32 or float64 are checked. Non-finite values nested in other values
+// still cause an error.
+func WithNonFiniteFloatsAsNull() EncodeOption {
+	return func(o *encodeOptions) {
+		o.nonFiniteFloatsAsNull = true
+	}
+}
+
+// WithStdlibJSON causes extension values to be encoded using the encoding/json package from the standard library
+// instead of github.com/go-json-experiment/json.
+//
+// This can be used when extension values depend on the exact behaviour of encoding/json, for example how nil slices
+// and maps are encoded. The overall structure of the generated JSON, including the order of the members and the
+// hoisting of extensions, is not affected by this option.
+//
+// Only the encoding of extension values is changed. The problem object itself is still written using
+// github.com/go-json-experiment/json, which is also used for parsing, so this option does not remove the dependency
+// on that package.
+func WithStdlibJSON() EncodeOption {
+	return func(o *encodeOptions) {
+		o.stdlibJSON = true
+	}
+}
+
+// WithMemberOrder changes the order in which the members defined by RFC 9457 are written.
+//
+// Each name in order must be one of [ReservedMembers] and may only be given once. Members not included in order
+// are written afterward, in the default order. Extensions are always written after all other members.
+//
+// If order is invalid, marshaling fails with an error.
+func WithMemberOrder(order []string) EncodeOption {
+	return func(o *encodeOptions) {
+		o.memberOrder, o.err = memberOrder(order)
+	}
+}
+
+func memberOrder(order []string) ([]string, error) {
+	result := make([]string, 0, len(reservedMembers))
+
+	for _, name := range order {
+		if !isReservedMember(name) {
+			return nil, fmt.Errorf("problem: invalid member %q in member order", name)
+		}
+
+		if slices.Contains(result, name) {
+			return nil, fmt.Errorf("problem: duplicate member %q in member order", name)
+		}
+
+		result = append(result, name)
+	}
+
+	for _, name := range reservedMembers {
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// WithoutInstance causes [Details.Instance] to be omitted from the generated JSON.
+//
+// This can be used when the instance may contain sensitive information, for example user-identifying paths, that
+// should not be exposed to clients, while still keeping the instance on the [Details] value for logging.
+func WithoutInstance() EncodeOption {
+	return func(o *encodeOptions) {
+		o.withoutInstance = true
+	}
+}
+
+// WithContentLength causes [Serve] to set the Content-Length header to the length of the encoded problem.
+//
+// By default, Serve removes any existing Content-Length header, which usually results in a chunked response. Since
+// the problem is always fully encoded before writing, even when using [WithStreaming], setting the header has no
+// additional cost, but it must not be used if the response writer modifies the body, for example by compressing it.
+func WithContentLength() EncodeOption {
+	return func(o *encodeOptions) {
+		o.contentLength = true
+	}
+}
+
+// WithStreaming causes [Serve] to encode the problem directly into the response writer instead of encoding it into
+// a buffer first.
+//
+// To make sure that nothing is written if encoding fails, the problem is encoded twice: once, discarding the output,
+// to check for errors and to determine the length, and once more to write the response. This trades CPU time for
+// memory and is only useful for problems with large extensions.
+//
+// WithStreaming has no effect on problems encoded as XML.
+func WithStreaming() EncodeOption {
+	return func(o *encodeOptions) {
+		o.streaming = true
+	}
+}
+
+// WithExposedDetail causes the error message of [Details.Underlying] to be used as detail if d has no Detail.
+//
+// The [Details] value itself is not modified. See also [ExposeDetail].
+func WithExposedDetail() EncodeOption {
+	return func(o *encodeOptions) {
+		o.exposeDetail = true
+	}
+}
+
+// WithSanitizedText causes control characters in the title and detail to be sanitized when marshaling.
+//
+// Whitespace control characters like newlines and tabs are replaced with a single space, while all other control
+// characters are removed. This prevents, for example, error messages from upstream services from corrupting logs.
+//
+// The [Details] value itself is not modified.
+func WithSanitizedText() EncodeOption {
+	return func(o *encodeOptions) {
+		o.sanitizeText = true
+	}
+}
+
+// text returns s, sanitized if requested via [WithSanitizedText].
+func (o *encodeOptions) text(s string) string {
+	if !o.sanitizeText {
+		return s
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case !unicode.IsControl(r):
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return -1
+		}
+	}, s)
+}
+
+// StatusFromTitle configures a mapping from titles to status codes, that is used for problems without a status.
+//
+// When marshaling or serving a [Details] with a zero Status, the status is looked up using the Title and, if found,
+// used instead. Otherwise, the status is left as is. The [Details] value itself is not modified.
+//
+// This is opt-in and mainly useful for code bases that identify problems by their title.
+func StatusFromTitle(m map[string]int) EncodeOption {
+	return func(o *encodeOptions) {
+		o.statusFromTitle = m
+	}
+}
+
+// WithContentTypeSuffix causes [Serve] to use the media type "application/<subtype>+json" instead of [ContentType],
+// for example "application/vnd.acme.problem+json" for the subtype "vnd.acme.problem".
+//
+// This can be used for versioning APIs via media types. Clients must use [WithLenientContentType] to parse such
+// responses using [From].
+func WithContentTypeSuffix(subtype string) EncodeOption {
+	return func(o *encodeOptions) {
+		o.contentType = "application/" + subtype + "+json"
+	}
+}
+
+// WithSnakeCaseExtensions causes extension keys written in camelCase or PascalCase to be converted to snake_case,
+// for example "invalidParams" to "invalid_params" and "HTTPStatus" to "http_status".
+//
+// Keys that are already in snake_case, or that contain no upper case letters, are unchanged. Extensions whose
+// converted key collides with a reserved member are handled according to [ExtensionCollisionPolicy], same as for
+// unconverted keys.
+//
+// If multiple extensions end up with the same key, only one of them is encoded. An extension whose key is already in
+// snake_case takes precedence, for example "foo_bar" over "fooBar". Otherwise, the extension whose original key sorts
+// first is used.
+func WithSnakeCaseExtensions() EncodeOption {
+	return func(o *encodeOptions) {
+		o.snakeCaseExtensions = true
+	}
+}
+
+// extensionKey returns the key to use for the extension k, taking into account [WithSnakeCaseExtensions].
+func (o *encodeOptions) extensionKey(k string) string {
+	if !o.snakeCaseExtensions {
+		return k
+	}
+
+	return snakeCase(k)
+}
+
+// sortedExtensionKeys returns the keys of the given extensions, sorted by the key used for marshaling.
+//
+// If multiple keys are converted to the same key by [WithSnakeCaseExtensions], only the one that takes precedence is
+// returned.
+func (o *encodeOptions) sortedExtensionKeys(extensions map[string]any) []string {
+	keys := slices.AppendSeq(make([]string, 0, len(extensions)), maps.Keys(extensions))
+
+	if !o.snakeCaseExtensions {
+		slices.Sort(keys)
+		return keys
+	}
+
+	// converted returns 1 if k is changed by snakeCase, so that keys already in snake_case are sorted first.
+	converted := func(k string) int {
+		if snakeCase(k) == k {
+			return 0
+		}
+
+		return 1
+	}
+
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(snakeCase(a), snakeCase(b)), cmp.Compare(converted(a), converted(b)), cmp.Compare(a, b))
+	})
+
+	return slices.CompactFunc(keys, func(a, b string) bool {
+		return snakeCase(a) == snakeCase(b)
+	})
+}
+
+// hasExtensionKey returns true if any of the given extension keys is marshaled as k.
+func (o *encodeOptions) hasExtensionKey(keys []string, k string) bool {
+	return slices.ContainsFunc(keys, func(key string) bool {
+		return o.extensionKey(key) == k
+	})
+}
+
+func snakeCase(s string) string {
+	rs := []rune(s)
+
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+
+	for i, r := range rs {
+		if !unicode.IsUpper(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		if i > 0 && rs[i-1] != '_' {
+			prevLower := unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+
+			if prevLower || (unicode.IsUpper(rs[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// status returns the status for d, taking into account the mapping configured via [StatusFromTitle].
+func (o *encodeOptions) status(d *Details) int {
+	if d.Status == 0 && d.Title != "" {
+		return o.statusFromTitle[d.Title]
+	}
+
+	return d.Status
+}
+
+// Marshal returns the JSON encoding of d using the given options.
+//
+// The result is always valid UTF-8 without a byte order mark. Unlike when calling [json.Marshal] directly, invalid
+// UTF-8 in extensions is also replaced with the Unicode replacement character U+FFFD instead of causing an error.
+func Marshal(d *Details, opts ...EncodeOption) ([]byte, error) {
+	return marshal(d, newEncodeOptions(opts))
+}
+
+func marshal(d *Details, o *encodeOptions) ([]byte, error) {
+	return json.Marshal(&optionsMarshaler{d: d, o: o}, jsonOptions)
+}
+
+// marshalWrite is like marshal, but writes the result to w.
+func marshalWrite(w io.Writer, d *Details, o *encodeOptions) error {
+	return json.MarshalWrite(w, &optionsMarshaler{d: d, o: o}, jsonOptions)
+}
+
+// optionsMarshaler marshals d using the options o.
+//
+// This avoids creating a new marshaler for *Details on each call, which is expensive. Problems nested inside
+// extensions are marshaled using [Details.MarshalJSONTo] and do not use o.
+type optionsMarshaler struct {
+	d *Details
+	o *encodeOptions
+}
+
+func (m *optionsMarshaler) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return m.d.marshalJSONTo(enc, m.o)
+}
+
+// numberMarshalers encodes numbers decoded using [WithJSONNumbers] as numbers, even when nested inside other values.
+//
+// This is created only once, since the json package caches marshalers per instance.
+var numberMarshalers = json.MarshalToFunc(marshalNumber)
+
+// jsonOptions are the options used for marshaling problems.
+var jsonOptions = json.JoinOptions(
+	// Replace invalid UTF-8 in extensions with U+FFFD instead of failing, so that a malformed string can not
+	// prevent a problem from being served.
+	jsontext.AllowInvalidUTF8(true),
+	// Sort the keys of maps nested inside extensions, same as for the extensions themselves.
+	json.Deterministic(true),
+	json.WithMarshalers(numberMarshalers))
+
+// marshalNumber writes n as JSON number.
+func marshalNumber(enc *jsontext.Encoder, n stdjson.Number) error {
+	return enc.WriteValue(jsontext.Value(n))
+}
+
+// countingWriter discards all written data, but counts the number of bytes written.
+type countingWriter struct {
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += len(p)
+	return len(p), nil
+}
+
+// Serve encodes d as JSON using the given options and writes it to the given response writer.
+//
+// If encoding fails, no data will be written and Serve will panic, unless [OnMarshalError] returns a replacement.
+//
+// Serve deletes any existing Content-Length header (see also [WithContentLength]), sets Content-Type to
+// “application/problem+json” (see also [WithContentTypeSuffix]), and sets X-Content-Type-Options to “nosniff”.
+//
+// Any headers in [Details.Header] are added to the response before setting the headers above. Any trailers in
+// [Details.Trailer] are announced using the Trailer header and written after the body.
+//
+// If set the Status field is used to set the HTTP status. Otherwise [http.StatusInternalServerError] is used.
+//
+// If the request was handled by [RequestIDHandler], the request ID is added to the response using the
+// [RequestIDExtension]. r may be nil.
+func Serve(w http.ResponseWriter, r *http.Request, d *Details, opts ...EncodeOption) {
+	if err := serve(w, r, d, newEncodeOptions(opts)); err != nil {
+		// If we get an error here we consider this a bug and panic.
+		panic(err)
+	}
+}
+
+func serve(w http.ResponseWriter, r *http.Request, d *Details, o *encodeOptions) error {
+	d = withRequestID(r, d)
+
+	contentType := cmp.Or(o.contentType, ContentType)
+
+	if o.xml {
+		contentType = ContentTypeXML
+	}
+
+	streaming := o.streaming && !o.xml
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// encode returns the encoded problem and its length. When streaming, the problem is encoded without keeping the
+	// output, only to check for errors and to get the length.
+	encode := func(d *Details) ([]byte, int, error) {
+		switch {
+		case streaming:
+			var c countingWriter
+			err := marshalWrite(&c, d, o)
+			return nil, c.n, err
+		case o.xml:
+			b, err := marshalXML(d, o)
+			return b, len(b), err
+		default:
+			buf.Reset()
+			err := marshalWrite(buf, d, o)
+			return buf.Bytes(), buf.Len(), err
+		}
+	}
+
+	b, n, err := encode(d)
+	if err != nil && OnMarshalError != nil {
+		if replacement := OnMarshalError(d, err); replacement != nil {
+			d = replacement
+			b, n, err = encode(d)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h := w.Header()
+
+	for k, vs := range d.Header {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+
+	// Remove the Content-Length header and set X-Content-Type-Options as done by [http.Error].
+	h.Del("Content-Length")
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	if o.contentLength {
+		h.Set("Content-Length", strconv.Itoa(n))
+	}
+
+	if len(d.Trailer) > 0 {
+		for _, k := range slices.Sorted(maps.Keys(d.Trailer)) {
+			h.Add("Trailer", k)
+		}
+	}
+
+	if status := o.status(d); status != 0 {
+		w.WriteHeader(status)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	if streaming {
+		_ = marshalWrite(w, d, o)
+	} else {
+		_, _ = w.Write(b)
+	}
+
+	for k, vs := range d.Trailer {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+
+	return nil
+}
+
+// From returns the problem returned as part of the given HTTP response if any.
+//
+// As a special case, if [Details.Status] would be 0, it will instead be set to the response status code.
+//
+// The Retry-After header of the response is always copied to [Details.Header]. See also [Details.RetryAfter].
+//
+// The response body will be closed automatically. At most [DefaultMaxBodySize] bytes are read from the body, unless
+// a different limit is set using [WithMaxBodySize]. If the body is larger, [ErrBodyTooLarge] is returned.
+//
+// If the response is not of type application/problem+json, the function returns nil, nil without reading or closing
+// the body, so the body can still be read by the caller. See also [FromOrBody].
+func From(resp *http.Response, opts ...FromOption) (*Details, error) {
+	return FromContext(context.Background(), resp, opts...)
+}
+
+// FromContext is like [From], but stops reading the response body once the given context is canceled.
+//
+// If the context is canceled or its deadline is exceeded while reading the body, the body is closed and the context
+// error is returned.
+func FromContext(ctx context.Context, resp *http.Response, opts ...FromOption) (*Details, error) {
+	o := newFromOptions(opts)
+
+	if !o.accepts(resp.Header.Get("Content-Type")) {
+		return nil, nil
+	}
+
+	body, err := o.readBody(ctx, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.parse(body, resp.StatusCode, resp.Header)
+}
+
+// FromOrBody is like [From], but also reads the body if the response is not a problem.
+//
+// If the response is of type application/problem+json, FromOrBody returns the parsed problem and a nil body.
+// Otherwise it returns a nil problem and the body of the response. The same size limit as for problems applies.
+//
+// In both cases the body is read and closed, so resp.Body can not be read again after the call.
+func FromOrBody(resp *http.Response, opts ...FromOption) (*Details, []byte, error) {
+	o := newFromOptions(opts)
+
+	body, err := o.readBody(context.Background(), resp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !o.accepts(resp.Header.Get("Content-Type")) {
+		return nil, body, nil
+	}
+
+	d, err := o.parse(body, resp.StatusCode, resp.Header)
+	return d, nil, err
+}
+
+// readBody reads and closes the body of resp, taking into account [WithMaxBodySize].
+func (o *fromOptions) readBody(ctx context.Context, resp *http.Response) ([]byte, error) {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// Closing the body unblocks any pending reads, so that we do not have to wait for the next chunk.
+	stop := context.AfterFunc(ctx, func() {
+		_ = resp.Body.Close()
+	})
+	defer stop()
+
+	var r io.Reader = &contextReader{ctx: ctx, r: resp.Body}
+
+	limit := cmp.Or(o.maxBodySize, DefaultMaxBodySize)
+
+	// A limit of math.MaxInt64 can never be exceeded, so it is handled the same as no limit. This also avoids an
+	// overflow when adding one below.
+	if limit == math.MaxInt64 {
+		limit = -1
+	}
+
+	if limit >= 0 {
+		// Read one more byte than allowed, so that we can detect bodies exceeding the limit.
+		r = io.LimitReader(r, limit+1)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		return nil, err
+	}
+
+	if limit >= 0 && int64(len(body)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+
+	return body, nil
+}
+
+// Parse parses the given body as problem details if the content type is application/problem+json.
+//
+// Parse is like [From], but works on an already read body. If the parsed status is 0, it is set to fallbackStatus.
+//
+// If the content type is not application/problem+json, the function returns nil, nil.
+//
+// Since there is no response, [WithCapturedHeaders] has no effect when used with Parse.
+func Parse(contentType string, body []byte, fallbackStatus int, opts ...FromOption) (*Details, error) {
+	o := newFromOptions(opts)
+
+	if !o.accepts(contentType) {
+		return nil, nil
+	}
+
+	return o.parse(body, fallbackStatus, nil)
+}
+
+// ParseString is like [Parse], but takes the body as string.
+func ParseString(contentType, body string, fallbackStatus int, opts ...FromOption) (*Details, error) {
+	return Parse(contentType, []byte(body), fallbackStatus, opts...)
+}
+
+// FromBytes parses the given body as problem details if the content type is application/problem+json.
+//
+// Media type parameters like "charset=utf-8" are allowed. If the content type is not application/problem+json, the
+// function returns nil, nil.
+//
+// FromBytes can be used to parse problems from already read response bodies or from sources other than an
+// [http.Response]. It is the same as calling [Parse] with a fallback status of 0.
+func FromBytes(contentType string, body []byte) (*Details, error) {
+	return Parse(contentType, body, 0)
+}
+
+// MaxTextDetailLength is the maximum number of bytes read from the response body by [FromTextResponse].
+const MaxTextDetailLength = 1024
+
+// FromTextResponse returns a new [Details] for a response that does not contain problem details, for example a
+// plain-text error response.
+//
+// The returned value has no type, the status of the response and [http.StatusText] as title. The response body is
+// used as detail, with surrounding whitespace and invalid UTF-8 removed. If the body is longer than
+// [MaxTextDetailLength] bytes, it is truncated and "..." is appended.
+//
+// The response body will be closed automatically. Errors while reading the body are ignored.
+func FromTextResponse(resp *http.Response) *Details {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, MaxTextDetailLength+1))
+
+	truncated := len(b) > MaxTextDetailLength
+	if truncated {
+		b = b[:MaxTextDetailLength]
+	}
+
+	// This also removes partial runes at the end of truncated bodies.
+	detail := strings.TrimSpace(strings.ToValidUTF8(string(b), ""))
+
+	if truncated {
+		detail += "..."
+	}
+
+	return Blank(resp.StatusCode, detail)
+}
+
+// FromOrStatus returns the problem returned as part of the given HTTP response or, if the response does not contain a
+// problem, a new [Details] created from the response status.
+//
+// The response is first parsed using [From] with the given options. If it is not a problem, the returned value is
+// created using [FromStatus] with the status of the response, so that it uses the default type registered for the
+// status using [Registry.RegisterDefaultForStatus], if any. A problem contained in the response always takes
+// precedence over the registered default. If the response contains an invalid problem, the parse error is used as
+// Underlying error of the returned value.
+//
+// As with From, the Retry-After header of the response is always copied to [Details.Header].
+//
+// FromOrStatus is meant to be used for error responses and does not check the status. The response body is always
+// closed. See also [FromTextResponse] for using the body of non-problem responses as detail.
+func FromOrStatus(resp *http.Response, opts ...FromOption) *Details {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	d, err := From(resp, opts...)
+	if d != nil {
+		return d
+	}
+
+	d = FromStatus(resp.StatusCode, WithUnderlying(err))
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		WithHeader("Retry-After", v)(d)
+	}
+
+	return d
+}
+
+func newFromOptions(opts []FromOption) *fromOptions {
+	var o fromOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &o
+}
+
+// accepts returns true if the given content type can be parsed as problem details.
+func (o *fromOptions) accepts(contentType string) bool {
+	if isContentType(ContentType, contentType) {
+		return true
+	}
+
+	if !o.lenientContentType {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
+
+// parse parses body as problem details and applies the options to the result.
+func (o *fromOptions) parse(body []byte, fallbackStatus int, header http.Header) (*Details, error) {
+	var d Details
+
+	if o.ignoredMembers || o.jsonNumbers {
+		var m map[string]any
+
+		var opts []json.Options
+		if o.jsonNumbers {
+			opts = append(opts, jsonNumbers)
+		}
+
+		if err := json.Unmarshal(body, &m, opts...); err != nil {
+			return nil, err
+		}
+
+		ignored := d.unmarshalMap(m)
+
+		if o.ignoredMembers {
+			d.IgnoredMembers = ignored
+		}
+	} else if err := json.Unmarshal(body, &d); err != nil {
+		return nil, err
+	}
+
+	o.finish(&d, fallbackStatus, header)
+
+	return &d, nil
+}
+
+// JSONNumbers returns options for [json.Unmarshal] and [json.UnmarshalDecode] that cause numbers in extensions to be
+// decoded as [encoding/json.Number] instead of float64 when unmarshaling a [Details].
+//
+// This is the same behaviour as [WithJSONNumbers], but can be used when unmarshaling a [Details] directly, for
+// example as part of a larger response. Other values that are decoded into an interface are affected as well.
+//
+// [Details.UnmarshalJSON] does not accept any options and always decodes numbers as float64.
+func JSONNumbers() json.Options {
+	return jsonNumbers
+}
+
+var jsonNumbers = json.WithUnmarshalers(json.UnmarshalFromFunc(unmarshalNumber))
+
+// unmarshalNumber decodes the next value as [encoding/json.Number] if it is a JSON number.
+func unmarshalNumber(dec *jsontext.Decoder, v *any) error {
+	if dec.PeekKind() != '0' {
+		return json.SkipFunc
+	}
+
+	b, err := dec.ReadValue()
+	if err != nil {
+		return err
+	}
+
+	*v = stdjson.Number(b)
+	return nil
+}
+
+// finish applies the options to the parsed details d.
+func (o *fromOptions) finish(d *Details, fallbackStatus int, header http.Header) {
+	if d.Status == 0 {
+		d.Status = fallbackStatus
+	}
+
+	if o.nestedExtensions {
+		if nested, ok := d.Extensions["extensions"].(map[string]any); ok {
+			delete(d.Extensions, "extensions")
+			maps.Copy(d.Extensions, nested)
+		}
+
+		if len(d.Extensions) == 0 {
+			d.Extensions = nil
+		}
+	}
+
+	if o.instanceBase != nil && d.Instance != "" {
+		d.Instance = resolveReference(o.instanceBase, d.Instance)
+	}
+
+	for _, name := range o.capturedHeaders {
+		for _, v := range header.Values(name) {
+			WithHeader(name, v)(d)
+		}
+	}
+
+	// Retry-After is always captured, so that clients can use [Details.RetryAfter].
+	if v := header.Get("Retry-After"); v != "" && d.Header.Get("Retry-After") == "" {
+		WithHeader("Retry-After", v)(d)
+	}
+}
+
+// contextReader wraps an [io.Reader] and fails all reads once the context is done.
+type contextReader struct {
+	ctx context.Context //nolint:containedctx // only used for a single call
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
+
+func resolveReference(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+
+	return base.ResolveReference(u).String()
+}
+
+func isContentType(expected, actual string) bool {
+	if !strings.HasPrefix(actual, expected) {
+		return false
+	}
+
+	return actual == expected || actual[len(expected)] == ';'
+}
+
+var _ error = (*Details)(nil)
+
+// Error implements the error interface. The returned value is the same as d.Title.
+func (d *Details) Error() string {
+	return d.Title
+}
+
+// Unwrap implements the interface used functions like [errors.Is] and [errors.As] to get the underlying error, if any.
+func (d *Details) Unwrap() error {
+	return d.Underlying
+}
+
+// CorrelationID returns the correlation ID set via [WithCorrelationID] or parsed from JSON, if any.
+//
+// If the extension is not set or not a string, an empty string is returned.
+func (d *Details) CorrelationID() string {
+	id, _ := d.Extensions[CorrelationIDExtension].(string)
+	return id
+}
+
+// TypeFragment returns the unescaped fragment of the problem type URI, if any.
+//
+// Some APIs use the fragment to further categorize problems of the same type, for example
+// "https://example.com/probs/out-of-credit#overdraft".
+//
+// If the type has no fragment or can not be parsed as URI reference, an empty string is returned.
+func (d *Details) TypeFragment() string {
+	u, err := url.Parse(d.Type)
+	if err != nil {
+		return ""
+	}
+
+	return u.Fragment
+}
+
+// Summary returns a short, human-readable message describing d, for example for showing it to users.
+//
+// Summary returns the first non-empty value of [Details.Detail], [Details.Title] and the [http.StatusText] of
+// [Details.Status]. If all of them are empty, a generic message is returned.
+func (d *Details) Summary() string {
+	return cmp.Or(d.Detail, d.Title, http.StatusText(d.Status), "An unknown error occurred.")
+}
+
+// IsClientError returns true if the status of d is a client error (4xx).
+func (d *Details) IsClientError() bool {
+	return d.Status >= 400 && d.Status < 500
+}
+
+// IsServerError returns true if the status of d is a server error (5xx).
+//
+// Since problems without a status are served with [http.StatusInternalServerError], a zero status is also considered
+// a server error.
+func (d *Details) IsServerError() bool {
+	return d.Status == 0 || (d.Status >= 500 && d.Status < 600)
+}
+
+// Meta returns the metadata value for key set via [WithMeta] or nil if there is no such value.
+func (d *Details) Meta(key string) any {
+	return d.meta[key]
+}
+
+// Headers returns a copy of [Details.Header], for example as captured by [From] using [WithCapturedHeaders].
+//
+// If no headers are set, Headers returns nil.
+func (d *Details) Headers() http.Header {
+	return d.Header.Clone()
+}
+
+// Normalize returns a copy of d in canonical form, for example for comparing or re-serving a problem parsed using
+// [From].
+//
+// The following normalizations are applied:
+//
+//   - An empty type is replaced with [AboutBlankTypeURI].
+//   - An empty title is replaced with the [http.StatusText] of the status, if any.
+//   - Extension values of type float64 without a fractional part are converted to int, including values nested
+//     inside []any and map[string]any, as created when parsing JSON. The same applies to integer values of type
+//     [encoding/json.Number], as created when using [WithJSONNumbers]. Other json.Number values are left as is.
+//
+// d itself is not modified.
+func (d *Details) Normalize() *Details {
+	c := d.Clone()
+	c.Type = cmp.Or(c.Type, AboutBlankTypeURI)
+	c.Title = cmp.Or(c.Title, http.StatusText(c.Status))
+
+	for k, v := range c.Extensions {
+		c.Extensions[k] = normalizeValue(v)
+	}
+
+	return c
+}
+
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case float64, stdjson.Number:
+		if i, ok := intValue(v); ok {
+			return i
 		}
-		d.Extensions[key] = value
-	}
-}
 
-// WithExtensions adds the values to the Extensions of a new Details value.
-func WithExtensions(extensions map[string]any) Option {
-	return func(d *Details) {
-		if d.Extensions == nil {
-			d.Extensions = make(map[string]any, len(extensions))
+		return v
+	case []any:
+		c := make([]any, len(v))
+
+		for i, e := range v {
+			c[i] = normalizeValue(e)
 		}
-		maps.Copy(d.Extensions, extensions)
-	}
-}
 
-// WithUnderlying sets the given value as the underlying error of a new Details value.
-func WithUnderlying(err error) Option {
-	return func(d *Details) {
-		d.Underlying = err
+		return c
+	case map[string]any:
+		c := make(map[string]any, len(v))
+
+		for k, e := range v {
+			c[k] = normalizeValue(e)
+		}
+
+		return c
+	default:
+		return v
 	}
 }
 
-// New returns a new Details instance using the given type, status and title.
+// Clone returns a copy of d that can be modified without affecting d, for example to customize a shared problem for
+// a single request.
 //
-// It is also possible to set the Detail and Instance fields as well as extensions by
-// providing one or more [Option] values.
-//
-// Most users should prefer creating a Details instance via a struct literal or using [Type.Details] instead.
-func New(typ string, title string, status int, opts ...Option) *Details {
-	p := &Details{
-		Type:   typ,
-		Status: status,
-		Title:  title,
-	}
-
-	for _, opt := range opts {
-		opt(p)
+// The Extensions map is copied, but the values inside it are not. The same applies to Header, Trailer and
+// IgnoredMembers. If d is nil, Clone returns nil.
+func (d *Details) Clone() *Details {
+	if d == nil {
+		return nil
 	}
 
-	return p
+	c := *d
+	c.Extensions = maps.Clone(c.Extensions)
+	c.Header = c.Header.Clone()
+	c.Trailer = c.Trailer.Clone()
+	c.IgnoredMembers = maps.Clone(c.IgnoredMembers)
+	c.meta = maps.Clone(c.meta)
+	return &c
 }
 
-// From returns the problem returned as part of the given HTTP response if any.
-//
-// As a special case, if [Details.Status] would be 0, it will instead be set to the response status code.
+// Merge returns a new Details value with the fields of other applied on top of a copy of d.
 //
-// The response body will be closed automatically.
+// Non-zero fields of other replace the respective fields of d. Extensions are combined, with values from other taking
+// precedence for keys that exist in both. The same applies to Header and Trailer, where the values of other replace
+// all values for the same key. The Underlying error of other is only used if it is not nil.
 //
-// If the response is not of type application/problem+json, the function returns nil, nil and does not close the body.
-func From(resp *http.Response) (*Details, error) {
-	ct := resp.Header.Get("Content-Type")
+// Neither d nor other are modified. If d is nil, Merge returns a copy of other.
+func (d *Details) Merge(other *Details) *Details {
+	if d == nil {
+		return other.Clone()
+	}
 
-	if !isContentType(ContentType, ct) {
-		return nil, nil
+	c := d.Clone()
+
+	if other == nil {
+		return c
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	c.Type = cmp.Or(other.Type, c.Type)
+	c.Status = cmp.Or(other.Status, c.Status)
+	c.Title = cmp.Or(other.Title, c.Title)
+	c.Detail = cmp.Or(other.Detail, c.Detail)
+	c.Instance = cmp.Or(other.Instance, c.Instance)
+	c.Extensions = mergeMaps(c.Extensions, other.Extensions)
+	c.Header = mergeMaps(c.Header, other.Header.Clone())
+	c.Trailer = mergeMaps(c.Trailer, other.Trailer.Clone())
+	c.IgnoredMembers = mergeMaps(c.IgnoredMembers, other.IgnoredMembers)
+	c.meta = mergeMaps(c.meta, other.meta)
+
+	if other.Underlying != nil {
+		c.Underlying = other.Underlying
+	}
 
-	var d Details
+	return c
+}
 
-	if err := json.UnmarshalRead(resp.Body, &d); err != nil {
-		return nil, err
+// mergeMaps copies all entries from src into dst, allocating dst if needed, and returns dst.
+func mergeMaps[M ~map[K]V, K comparable, V any](dst, src M) M {
+	if len(src) == 0 {
+		return dst
 	}
 
-	if d.Status == 0 {
-		d.Status = resp.StatusCode
+	if dst == nil {
+		dst = make(M, len(src))
 	}
 
-	return &d, nil
+	maps.Copy(dst, src)
+	return dst
 }
 
-func isContentType(expected, actual string) bool {
-	if !strings.HasPrefix(actual, expected) {
-		return false
+// RetryAfter returns the delay given by the Retry-After header in [Details.Header], if any.
+//
+// Both the delay-seconds and the HTTP-date form are supported. For dates in the past a delay of 0 is returned. If the
+// header is missing or invalid, RetryAfter returns false.
+//
+// [From] always captures the Retry-After header of the response, so that RetryAfter can be used to implement
+// backoff in clients.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9110#name-retry-after
+func (d *Details) RetryAfter() (time.Duration, bool) {
+	v := d.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
 	}
 
-	return actual == expected || actual[len(expected)] == ';'
+	if secs, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(time.Until(t), 0), true
 }
 
-var _ error = (*Details)(nil)
+// Apply applies the given options to d and returns d.
+//
+// Unlike [New] and [Type.Details], Apply does not create a new value but modifies d in place. Callers must make sure
+// that d is not shared, for example when d is a package-level variable.
+func (d *Details) Apply(opts ...Option) *Details {
+	for _, opt := range opts {
+		opt(d)
+	}
 
-// Error implements the error interface. The returned value is the same as d.Title.
-func (d *Details) Error() string {
-	return d.Title
+	return d
 }
 
-// Unwrap implements the interface used functions like [errors.Is] and [errors.As] to get the underlying error, if any.
-func (d *Details) Unwrap() error {
-	return d.Underlying
+// AsType returns a new [Type] using the type URI, title, status and extensions of d.
+//
+// Occurrence-specific fields like Detail, Instance and Underlying are not part of a [Type] and are dropped.
+//
+// The extensions are copied and can be modified without affecting d.
+func (d *Details) AsType() *Type {
+	return &Type{
+		URI:        d.Type,
+		Title:      d.Title,
+		Status:     d.Status,
+		Extensions: maps.Clone(d.Extensions),
+	}
 }
 
 // MarshalJSON implements the json.Marshaler interface.
 //
 // See MarshalJSONTo for details.
 func (d *Details) MarshalJSON() ([]byte, error) {
-	// This will call (*Details).MarshalJSONTo.
-	return json.Marshal(d)
+	// This will call (*Details).marshalJSONTo, using the same options as [Marshal].
+	return marshal(d, &encodeOptions{})
 }
 
 var _ json.MarshalerTo = (*Details)(nil)
@@ -213,8 +1722,19 @@ var _ json.MarshalerTo = (*Details)(nil)
 // If no Type is set, "about:blank" is used. See also [AboutBlankTypeURI].
 //
 // Extension fields named "type", "status", "title", "detail" or "instance" are ignored when marshaling in favor
-// of the respective struct fields even if the field is empty.
+// of the respective struct fields even if the field is empty. See also [ReservedMembers] and
+// [ExtensionCollisionPolicy] for changing this behaviour.
+//
+// Extensions are written after the members defined by RFC 9457, sorted by key, so that the output is deterministic.
+//
+// Extensions with a NaN or infinite float value cause an error. See also [WithNonFiniteFloatsAsNull].
+//
+// Invalid UTF-8 in the type, title, detail and instance is replaced with the Unicode replacement character U+FFFD.
 func (d *Details) MarshalJSONTo(enc *jsontext.Encoder) error {
+	return d.marshalJSONTo(enc, &encodeOptions{})
+}
+
+func (d *Details) marshalJSONTo(enc *jsontext.Encoder, o *encodeOptions) error {
 	// We implement marshalling ourselves so that we can put the defined fields and the extensions
 	// into a single JSON object.
 	//
@@ -223,82 +1743,165 @@ func (d *Details) MarshalJSONTo(enc *jsontext.Encoder) error {
 		return err
 	}
 
-	typ := cmp.Or(d.Type, AboutBlankTypeURI)
+	if o.err != nil {
+		return o.err
+	}
 
-	if d.Type != "" {
-		if err := enc.WriteToken(jsontext.String("type")); err != nil {
-			return err
-		}
+	order := o.memberOrder
+	if order == nil {
+		order = reservedMembers
+	}
 
-		if err := enc.WriteToken(jsontext.String(typ)); err != nil {
+	for _, name := range order {
+		if err := d.marshalMember(enc, o, name); err != nil {
 			return err
 		}
 	}
 
-	if d.Status != 0 {
-		if err := enc.WriteToken(jsontext.String("status")); err != nil {
-			return err
+	// nested is set once the object for nested extensions was opened. This is only done when the first extension is
+	// written, so that no empty object is written if all extensions are dropped.
+	var nested bool
+
+	keys := o.sortedExtensionKeys(d.Extensions)
+
+	for _, k := range keys {
+		v := d.Extensions[k]
+		k = o.extensionKey(k)
+
+		if isReservedMember(k) {
+			switch ExtensionCollisionPolicy {
+			case CollisionPrefix:
+				k = CollisionPrefixString + k
+
+				if o.hasExtensionKey(keys, k) {
+					continue
+				}
+			case CollisionError:
+				return fmt.Errorf("problem: extension %q collides with reserved member", k)
+			default:
+				continue
+			}
 		}
 
-		if err := enc.WriteToken(jsontext.Int(int64(d.Status))); err != nil {
-			return err
+		if o.nestedExtensions && !nested {
+			if err := enc.WriteToken(jsontext.String("extensions")); err != nil {
+				return err
+			}
+
+			if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+				return err
+			}
+
+			nested = true
 		}
-	}
 
-	if d.Title != "" {
-		if err := enc.WriteToken(jsontext.String("title")); err != nil {
+		if err := enc.WriteToken(jsontext.String(k)); err != nil {
 			return err
 		}
 
-		if err := enc.WriteToken(jsontext.String(d.Title)); err != nil {
-			return err
+		if isNonFiniteFloat(v) {
+			if !o.nonFiniteFloatsAsNull {
+				return fmt.Errorf("problem: extension %q has unsupported float value %v", k, v)
+			}
+
+			v = nil
 		}
-	}
 
-	if d.Detail != "" {
-		if err := enc.WriteToken(jsontext.String("detail")); err != nil {
+		if err := o.encodeValue(enc, v); err != nil {
 			return err
 		}
+	}
 
-		if err := enc.WriteToken(jsontext.String(d.Detail)); err != nil {
+	if nested {
+		if err := enc.WriteToken(jsontext.EndObject); err != nil {
 			return err
 		}
 	}
 
-	if d.Instance != "" {
-		if err := enc.WriteToken(jsontext.String("instance")); err != nil {
-			return err
+	if err := enc.WriteToken(jsontext.EndObject); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *Details) marshalMember(enc *jsontext.Encoder, o *encodeOptions, name string) error {
+	switch name {
+	case "type":
+		typ := cmp.Or(d.Type, DefaultTypeURI)
+		if typ == "" {
+			return nil
 		}
 
-		if err := enc.WriteToken(jsontext.String(d.Instance)); err != nil {
-			return err
+		if TypeURIRewriter != nil {
+			typ = TypeURIRewriter(typ)
 		}
-	}
 
-	for k, v := range d.Extensions {
-		if k == "type" || k == "status" || k == "title" || k == "detail" || k == "instance" {
-			continue
+		return marshalStringMember(enc, name, typ)
+	case "status":
+		status := o.status(d)
+
+		if status == 0 && !o.status0AsNull {
+			return nil
 		}
 
-		if err := enc.WriteToken(jsontext.String(k)); err != nil {
+		if err := enc.WriteToken(jsontext.String("status")); err != nil {
 			return err
 		}
 
-		if err := json.MarshalEncode(enc, v); err != nil {
-			return err
+		switch {
+		case status == 0:
+			return enc.WriteToken(jsontext.Null)
+		case o.floatStatus:
+			return enc.WriteValue(jsontext.Value(strconv.Itoa(status) + ".0"))
+		default:
+			return enc.WriteToken(jsontext.Int(int64(status)))
 		}
+	case "title":
+		return marshalStringMember(enc, name, o.text(d.Title))
+	case "detail":
+		if d.Detail == "" && d.Underlying != nil && (o.exposeDetail || ExposeDetail) {
+			return marshalStringMember(enc, name, o.text(d.Underlying.Error()))
+		}
+
+		return marshalStringMember(enc, name, o.text(d.Detail))
+	case "instance":
+		if o.withoutInstance {
+			return nil
+		}
+
+		return marshalStringMember(enc, name, d.Instance)
+	default:
+		return fmt.Errorf("problem: unknown member %q", name)
 	}
+}
 
-	if err := enc.WriteToken(jsontext.EndObject); err != nil {
+func marshalStringMember(enc *jsontext.Encoder, name string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if err := enc.WriteToken(jsontext.String(name)); err != nil {
 		return err
 	}
 
-	return nil
+	return enc.WriteToken(jsontext.String(strings.ToValidUTF8(value, string(utf8.RuneError))))
+}
+
+func isNonFiniteFloat(v any) bool {
+	switch v := v.(type) {
+	case float32:
+		return math.IsNaN(float64(v)) || math.IsInf(float64(v), 0)
+	case float64:
+		return math.IsNaN(v) || math.IsInf(v, 0)
+	default:
+		return false
+	}
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 //
-// See UnmarshalJSONV2 for details.
+// Numbers in extensions are always decoded as float64. See UnmarshalJSONFrom for details.
 func (d *Details) UnmarshalJSON(b []byte) error {
 	// This will call (*Details).UnmarshalJSONV2.
 	return json.Unmarshal(b, d)
@@ -312,6 +1915,9 @@ var _ json.UnmarshalerFrom = (*Details)(nil)
 //
 // For example if the parsed JSON contains a field "status" with the code "400" as a JSON string, the field will be
 // ignored even if it may be possible to parse it as an integer.
+//
+// Numbers in extensions are decoded as float64, unless the decoder was created using the options returned by
+// [JSONNumbers].
 func (d *Details) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
 	var m map[string]any
 
@@ -319,6 +1925,15 @@ func (d *Details) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
 		return err
 	}
 
+	d.unmarshalMap(m)
+
+	return nil
+}
+
+// unmarshalMap sets the fields of d from the members in m and returns all ignored members, if any.
+//
+// m must not be used after calling unmarshalMap.
+func (d *Details) unmarshalMap(m map[string]any) map[string]any {
 	//  3.1. Members of a Problem Details Object
 	//
 	// 	Problem detail objects can have the following members. If a member's
@@ -328,69 +1943,112 @@ func (d *Details) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
 	//
 	// https://datatracker.ietf.org/doc/html/rfc9457#name-members-of-a-problem-detail
 
-	if v, ok := m["type"].(string); ok {
-		d.Type = v
-	}
+	var ignored map[string]any
 
-	if v, ok := m["status"].(float64); ok && float64(int(v)) == v {
-		d.Status = int(v)
-	}
+	for _, name := range reservedMembers {
+		v, ok := m[name]
+		if !ok {
+			continue
+		}
+
+		delete(m, name)
 
-	if v, ok := m["title"].(string); ok {
-		d.Title = v
+		if !d.setMember(name, v) {
+			if ignored == nil {
+				ignored = make(map[string]any)
+			}
+
+			ignored[name] = v
+		}
 	}
 
-	if v, ok := m["detail"].(string); ok {
-		d.Detail = v
+	if len(m) != 0 {
+		d.Extensions = m
 	}
 
-	if v, ok := m["instance"].(string); ok {
-		d.Instance = v
+	return ignored
+}
+
+// setMember sets the field for the reserved member name to v, if v has the right type, and returns true if it did.
+func (d *Details) setMember(name string, v any) bool {
+	if name == "status" {
+		f, ok := v.(float64)
+
+		if n, isNumber := v.(stdjson.Number); isNumber {
+			var err error
+			f, err = n.Float64()
+			ok = err == nil
+		}
+
+		if ok && float64(int(f)) == f {
+			d.Status = int(f)
+			return true
+		}
+
+		return false
 	}
 
-	delete(m, "type")
-	delete(m, "status")
-	delete(m, "title")
-	delete(m, "detail")
-	delete(m, "instance")
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
 
-	if len(m) != 0 {
-		d.Extensions = m
+	switch name {
+	case "type":
+		d.Type = s
+	case "title":
+		d.Title = s
+	case "detail":
+		d.Detail = s
+	case "instance":
+		d.Instance = s
 	}
 
-	return nil
+	return true
 }
 
-// ServeHTTP encodes the value as JSON and writes it to the given response writer.
-//
-// If encoding fails, no data will be written and ServeHTTP will panic.
+// ServeHTTP encodes the value as JSON or XML, depending on the Accept header of the request, and writes it to the
+// given response writer.
 //
-// ServeHTTP deletes any existing Content-Length header, sets Content-Type to “application/problem+json”, and sets
-// X-Content-Type-Options to “nosniff”.
-//
-// If set the Status field is used to set the HTTP status. Otherwise [http.StatusInternalServerError] is used.
+// If the request prefers application/problem+xml over JSON, the value is encoded as XML as described in RFC 9457 and
+// served using [ContentTypeXML]. Extensions are encoded as child elements named after their key. Otherwise, it is
+// equivalent to calling Serve(w, r, d). See [Serve] for details.
 //
 // ServeHTTP implements the [http.Handler] interface.
-func (d *Details) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
-	b, err := json.Marshal(d)
-	if err != nil {
+func (d *Details) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+
+	if r == nil || !prefersXML(r.Header.Get("Accept")) {
+		Serve(w, r, d)
+		return
+	}
+
+	if err := serve(w, r, d, &encodeOptions{xml: true}); err != nil {
 		// If we get an error here we consider this a bug and panic.
 		panic(err)
 	}
+}
 
-	// Remove the Content-Length header and set X-Content-Type-Options as done by [http.Error].
-	h := w.Header()
-	h.Del("Content-Length")
-	h.Set("Content-Type", ContentType)
-	h.Set("X-Content-Type-Options", "nosniff")
+// Write encodes d as JSON using the given options and writes it to the given response writer.
+//
+// Write sets the same headers and status as [Serve], but does not require a request and returns an error instead of
+// panicking if d can not be encoded. In that case nothing is written to w, even when using [WithStreaming].
+//
+// Since there is no request, no request ID is added. See also [RequestIDHandler].
+func (d *Details) Write(w http.ResponseWriter, opts ...EncodeOption) error {
+	return serve(w, nil, d, newEncodeOptions(opts))
+}
 
-	if d.Status != 0 {
-		w.WriteHeader(d.Status)
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+// HandlerFunc returns a handler that serves d with the given options on every request.
+//
+// This can be used for fixed error routes, for example as a catch-all handler that always responds with a 404 problem.
+//
+// Serving a problem does not modify it, so the same value is used for all requests and must not be modified while the
+// handler is in use.
+func (d *Details) HandlerFunc(opts ...EncodeOption) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Serve(w, r, d, opts...)
 	}
-
-	_, _ = w.Write(b)
 }
 
 // Type defines a specific problem type that can be used to create new Details instances.
@@ -441,6 +2099,44 @@ type Type struct {
 	// Extensions contains fixed extensions that are automatically added to Details instances
 	// created from this type.
 	Extensions map[string]any
+
+	// Fragment optionally contains a URI fragment that is used by [Is] to match problems with sub-categorized type
+	// URIs, for example "overdraft" for the problem type "https://example.com/probs/out-of-credit#overdraft".
+	//
+	// Fragment is only used for matching and not used when creating Details instances.
+	Fragment string
+
+	// Statuses optionally contains a set of HTTP status codes that are matched by [Is] if Status is zero, for example
+	// 401 and 403 for a type describing authentication problems.
+	//
+	// Statuses is only used for matching and not used when creating Details instances. If Status is set, Statuses is
+	// ignored.
+	Statuses []int
+
+	// StickyExtensions contains the names of extensions from Extensions that can not be overridden using options
+	// when creating Details instances via [Type.Details].
+	//
+	// This can be used for extensions that must always have the same value for a type, for example a link to the
+	// documentation of the type.
+	StickyExtensions []string
+}
+
+// TypeURI returns a type URI by joining the given base URI and slug with exactly one slash.
+//
+// Example:
+//
+//	problem.TypeURI("https://example.com/probs/", "/out-of-credit") // "https://example.com/probs/out-of-credit"
+func TypeURI(base, slug string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(slug, "/")
+}
+
+// NewType returns a new [Type] with the given title and status and a URI created using [TypeURI].
+func NewType(base, slug, title string, status int) *Type {
+	return &Type{
+		URI:    TypeURI(base, slug),
+		Title:  title,
+		Status: status,
+	}
 }
 
 // Is returns true if the given error can be converted to a [*Details] using [errors.As] and the URI, Title and Status
@@ -449,6 +2145,17 @@ type Type struct {
 // If any of [Type.URI], [Type.Title] or [Type.Status] is empty / zero, the field is skipped.
 //
 // For example, for a type with only a URI and no title or status, only the URI will be compared.
+//
+// If [Type.Status] is zero and [Type.Statuses] is not empty, the status of the problem must be one of the given
+// statuses. [Type.Status] takes precedence over [Type.Statuses].
+//
+// If [Type.Fragment] is set, the fragment of the problem type URI (see [Details.TypeFragment]) must match the
+// fragment and the URI is compared without the fragment.
+//
+// When comparing URIs, an empty [Details.Type] is treated as "about:blank", so that a type with the URI
+// "about:blank" matches problems with either an empty type or an explicit "about:blank" type.
+//
+// [Type.Extensions] are ignored. Use [IsExact] to also compare extensions.
 func Is(err error, t *Type) bool {
 	var d *Details
 
@@ -456,22 +2163,61 @@ func Is(err error, t *Type) bool {
 		return false
 	}
 
+	typ := cmp.Or(d.Type, AboutBlankTypeURI)
+
+	if t.Fragment != "" {
+		typ, _, _ = strings.Cut(typ, "#")
+	}
+
 	switch {
-	case t.URI != "" && t.URI != cmp.Or(d.Type, AboutBlankTypeURI):
+	case t.URI != "" && t.URI != typ:
+		return false
+	case t.Fragment != "" && t.Fragment != d.TypeFragment():
 		return false
 	case t.Title != "" && t.Title != d.Title:
 		return false
 	case t.Status != 0 && t.Status != d.Status:
 		return false
+	case t.Status == 0 && len(t.Statuses) > 0 && !slices.Contains(t.Statuses, d.Status):
+		return false
 	default:
 		return true
 	}
 }
 
+// IsExact is like [Is], but additionally requires that all [Type.Extensions] are present in the problem.
+//
+// Extension values are compared using [reflect.DeepEqual], so values must also have the same type. Note that for
+// problems parsed from JSON, for example via [From], all numbers are of type float64. Extensions of the problem that
+// are not part of the type are ignored.
+//
+// If [Type.Extensions] is empty, IsExact is the same as [Is].
+func IsExact(err error, t *Type) bool {
+	if !Is(err, t) {
+		return false
+	}
+
+	var d *Details
+	_ = errors.As(err, &d)
+
+	for k, want := range t.Extensions {
+		got, ok := d.Extensions[k]
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Details creates a new [Details] instance from this type.
 //
 // It is equivalent to calling New(p.URI, p.Status, p.Title, opts...).
 func (t *Type) Details(opts ...Option) *Details {
+	if trackingEnabled.Load() {
+		markSeen(t)
+	}
+
 	d := New(t.URI, t.Title, t.Status)
 
 	// Note: Conceptually what we want is to pass our extensions to New via WithExtensions as
@@ -490,5 +2236,72 @@ func (t *Type) Details(opts ...Option) *Details {
 		opt(d)
 	}
 
+	for _, k := range t.StickyExtensions {
+		v, ok := t.Extensions[k]
+		if !ok {
+			continue
+		}
+
+		if d.Extensions == nil {
+			d.Extensions = make(map[string]any, len(t.StickyExtensions))
+		}
+
+		d.Extensions[k] = v
+	}
+
 	return d
 }
+
+// Wrap checks that d is consistent with t and returns a copy of d with missing values filled in from t.
+//
+// If d has no type URI, status or title, the respective value from t is used. Extensions of t that are not set on d
+// are added as well. If the type URI or status of d is set but differs from the one of t, an error describing all
+// conflicts is returned instead. Values that are not set on t are never treated as conflicting.
+//
+// This can be used to make sure that a handler does not serve a problem with, for example, the wrong status for a
+// declared type. d itself is never modified.
+func (t *Type) Wrap(d *Details) (*Details, error) {
+	var errs []error
+
+	if t.URI != "" && d.Type != "" && d.Type != t.URI {
+		errs = append(errs, fmt.Errorf("problem: type %q does not match %q", d.Type, t.URI))
+	}
+
+	if t.Status != 0 && d.Status != 0 && d.Status != t.Status {
+		errs = append(errs, fmt.Errorf("problem: status %d does not match %d for type %q", d.Status, t.Status, t.URI))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	c := d.Clone()
+	c.Type = cmp.Or(c.Type, t.URI)
+	c.Status = cmp.Or(c.Status, t.Status)
+	c.Title = cmp.Or(c.Title, t.Title)
+
+	for k, v := range t.Extensions {
+		if _, ok := c.Extensions[k]; ok {
+			continue
+		}
+
+		if c.Extensions == nil {
+			c.Extensions = make(map[string]any, len(t.Extensions))
+		}
+
+		c.Extensions[k] = v
+	}
+
+	return c, nil
+}
+
+// Minimal creates a new [Details] instance from this type, containing only the URI, title and status.
+//
+// Unlike [Type.Details], the extensions of the type are not included.
+func (t *Type) Minimal() *Details {
+	if trackingEnabled.Load() {
+		markSeen(t)
+	}
+
+	return New(t.URI, t.Title, t.Status)
+}
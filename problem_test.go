@@ -1,16 +1,27 @@
 package problem_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	jsonv2 "github.com/go-json-experiment/json"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 
 	"github.com/nussjustin/problem"
 )
@@ -55,6 +66,28 @@ func assertJSON(tb testing.TB, want string, got []byte) {
 	}
 }
 
+// ignoreUnexported ignores unexported fields of [problem.Details], like metadata set via [problem.WithMeta].
+var ignoreUnexported = cmpopts.IgnoreUnexported(problem.Details{})
+
+// equateUnderlying compares [problem.Details.Underlying] using [errors.Is], since errors from the standard library
+// can not be compared structurally.
+var equateUnderlying = cmp.FilterPath(func(p cmp.Path) bool {
+	f, ok := p.Last().(cmp.StructField)
+	return ok && f.Name() == "Underlying"
+}, cmp.Comparer(func(x, y error) bool {
+	return errors.Is(x, y) || errors.Is(y, x)
+}))
+
+// newResponse returns a response with the given status, Content-Type header and body, for example for testing
+// [problem.From].
+func newResponse(status int, contentType string, body io.Reader) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {contentType}},
+		Body:       io.NopCloser(body),
+	}
+}
+
 func assertResponse(tb testing.TB, rec *httptest.ResponseRecorder, wantStatus int, wantJSON string) {
 	tb.Helper()
 
@@ -77,6 +110,34 @@ func assertResponse(tb testing.TB, rec *httptest.ResponseRecorder, wantStatus in
 	assertJSON(tb, wantJSON, rec.Body.Bytes())
 }
 
+func TestReservedMembers(t *testing.T) {
+	got := problem.ReservedMembers()
+	want := []string{"type", "status", "title", "detail", "instance"}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReservedMembers() mismatch (-want +got):\n%s", diff)
+	}
+
+	got[0] = "modified"
+
+	if problem.ReservedMembers()[0] != "type" {
+		t.Errorf("ReservedMembers() returned shared slice")
+	}
+
+	extensions := map[string]any{"balance": 30}
+
+	for _, name := range want {
+		extensions[name] = "extension"
+	}
+
+	b, err := json.Marshal(&problem.Details{Extensions: extensions})
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"balance": 30}`, b)
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		Name     string
@@ -144,13 +205,360 @@ func TestNew(t *testing.T) {
 				http.StatusForbidden,
 				test.Opts...)
 
-			if diff := cmp.Diff(&test.Expected, d); diff != "" {
+			if diff := cmp.Diff(&test.Expected, d, ignoreUnexported); diff != "" {
 				t.Errorf("problem.New() mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
 
+func TestFromError(t *testing.T) {
+	err := errors.New("balance too low")
+
+	t.Run("Error", func(t *testing.T) {
+		got := problem.FromError(err,
+			"https://example.com/probs/out-of-credit",
+			"You do not have enough credit.",
+			http.StatusForbidden,
+			problem.WithExtension("balance", 30))
+
+		want := &problem.Details{
+			Type:       "https://example.com/probs/out-of-credit",
+			Title:      "You do not have enough credit.",
+			Status:     http.StatusForbidden,
+			Detail:     "balance too low",
+			Extensions: map[string]any{"balance": 30},
+			Underlying: err,
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported, equateUnderlying); diff != "" {
+			t.Errorf("problem.FromError() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("WithDetail", func(t *testing.T) {
+		got := problem.FromError(err, "", "Forbidden", http.StatusForbidden,
+			problem.WithDetail("Your current balance is 30, but that costs 50."))
+
+		if want := "Your current balance is 30, but that costs 50."; got.Detail != want {
+			t.Errorf("got detail %q, want %q", got.Detail, want)
+		}
+
+		if got.Underlying != err {
+			t.Errorf("got underlying error %v, want %v", got.Underlying, err)
+		}
+	})
+
+	t.Run("Details", func(t *testing.T) {
+		d := problem.New("", "Not Found", http.StatusNotFound)
+
+		got := problem.FromError(fmt.Errorf("wrapped: %w", d), "", "Forbidden", http.StatusForbidden,
+			problem.WithDetail("ignored"))
+
+		if got != d {
+			t.Errorf("got %#v, want %#v", got, d)
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		if got := problem.FromError(nil, "", "Forbidden", http.StatusForbidden); got != nil {
+			t.Errorf("got %#v, want nil", got)
+		}
+	})
+}
+
+func TestWithExtensionsIf(t *testing.T) {
+	debug := map[string]any{"query": "SELECT * FROM accounts"}
+
+	got := (&problem.Details{}).Apply(
+		problem.WithExtension("balance", 30),
+		problem.WithExtensionsIf(true, debug))
+
+	want := map[string]any{"balance": 30, "query": "SELECT * FROM accounts"}
+
+	if diff := cmp.Diff(want, got.Extensions); diff != "" {
+		t.Errorf("extensions mismatch (-want +got):\n%s", diff)
+	}
+
+	got = (&problem.Details{}).Apply(
+		problem.WithExtension("balance", 30),
+		problem.WithExtensionsIf(false, debug))
+
+	want = map[string]any{"balance": 30}
+
+	if diff := cmp.Diff(want, got.Extensions); diff != "" {
+		t.Errorf("extensions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithKV(t *testing.T) {
+	got := (&problem.Details{}).Apply(
+		problem.WithExtension("balance", 30),
+		problem.WithKV("currency", "EUR", "accounts", []string{"/account/12345"}))
+
+	want := map[string]any{"balance": 30, "currency": "EUR", "accounts": []string{"/account/12345"}}
+
+	if diff := cmp.Diff(want, got.Extensions); diff != "" {
+		t.Errorf("extensions mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("Odd count", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic was raised for odd number of arguments")
+			}
+		}()
+
+		problem.WithKV("currency", "EUR", "balance")
+	})
+
+	t.Run("Non-string key", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic was raised for non-string key")
+			}
+		}()
+
+		problem.WithKV(1, "EUR")
+	})
+}
+
+func TestExtensionCollisionPolicy(t *testing.T) {
+	defer func() {
+		problem.ExtensionCollisionPolicy = problem.CollisionDrop
+	}()
+
+	d := problem.New("", "Forbidden", http.StatusForbidden, problem.WithExtension("status", http.StatusTeapot))
+
+	tests := []struct {
+		Name     string
+		Details  *problem.Details
+		Policy   problem.CollisionPolicy
+		Expected string
+		Error    bool
+	}{
+		{
+			Name:     "Drop",
+			Policy:   problem.CollisionDrop,
+			Expected: `{"status": 403, "title": "Forbidden"}`,
+		},
+		{
+			Name:     "Prefix",
+			Policy:   problem.CollisionPrefix,
+			Expected: `{"status": 403, "title": "Forbidden", "ext_status": 418}`,
+		},
+		{
+			Name: "Prefix with existing key",
+			Details: problem.New("", "Forbidden", http.StatusForbidden,
+				problem.WithExtension("status", http.StatusTeapot),
+				problem.WithExtension("ext_status", "overloaded")),
+			Policy:   problem.CollisionPrefix,
+			Expected: `{"status": 403, "title": "Forbidden", "ext_status": "overloaded"}`,
+		},
+		{
+			Name:   "Error",
+			Policy: problem.CollisionError,
+			Error:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			problem.ExtensionCollisionPolicy = test.Policy
+
+			d := d
+			if test.Details != nil {
+				d = test.Details
+			}
+
+			b, err := json.Marshal(d)
+
+			switch {
+			case test.Error && err == nil:
+				t.Fatal("expected error, got nil")
+			case test.Error:
+				return
+			case err != nil:
+				t.Fatalf("failed to marshal details: %s", err)
+			}
+
+			assertJSON(t, test.Expected, b)
+		})
+	}
+}
+
+func TestWithType(t *testing.T) {
+	typ := &problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	}
+
+	got := typ.Details(problem.WithType("https://example.com/probs/out-of-credit/overdraft"))
+
+	want := &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit/overdraft",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("Type.Details() mismatch (-want +got):\n%s", diff)
+	}
+
+	d := problem.New("", "Forbidden", http.StatusForbidden, problem.WithType(problem.AboutBlankTypeURI))
+
+	if got, want := d.Type, problem.AboutBlankTypeURI; got != want {
+		t.Errorf("got type %q, want %q", got, want)
+	}
+}
+
+func TestWithTitle(t *testing.T) {
+	typ := &problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	}
+
+	got := typ.Details(problem.WithTitle("You do not have enough credit for this message."))
+
+	want := &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit for this message.",
+		Status: http.StatusForbidden,
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("Type.Details() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithTitleFromStatus(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Opts     []problem.Option
+		Expected string
+	}{
+		{
+			Name:     "Status",
+			Opts:     []problem.Option{problem.WithTitleFromStatus()},
+			Expected: "Forbidden",
+		},
+		{
+			Name:     "After WithStatus",
+			Opts:     []problem.Option{problem.WithStatus(http.StatusTeapot), problem.WithTitleFromStatus()},
+			Expected: "I'm a teapot",
+		},
+		{
+			Name:     "Before WithStatus",
+			Opts:     []problem.Option{problem.WithTitleFromStatus(), problem.WithStatus(http.StatusTeapot)},
+			Expected: "Forbidden",
+		},
+		{
+			Name:     "Unknown status",
+			Opts:     []problem.Option{problem.WithStatus(599), problem.WithTitleFromStatus()},
+			Expected: "Access denied",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := problem.New("", "Access denied", http.StatusForbidden, test.Opts...)
+
+			if d.Title != test.Expected {
+				t.Errorf("got title %q, want %q", d.Title, test.Expected)
+			}
+		})
+	}
+}
+
+func TestDetails_MarshalJSONTo_SortedExtensions(t *testing.T) {
+	d := problem.New("", "Forbidden", http.StatusForbidden,
+		problem.WithExtension("zulu", 1),
+		problem.WithExtension("alpha", map[string]any{"y": 1, "x": 2, "z": 3}),
+		problem.WithExtension("mike", 3),
+		problem.WithExtension("bravo", 4))
+
+	const want = `{"status":403,"title":"Forbidden","alpha":{"x":2,"y":1,"z":3},"bravo":4,"mike":3,"zulu":1}`
+
+	for range 10 {
+		b, err := problem.Marshal(d)
+		if err != nil {
+			t.Fatalf("failed to marshal details: %s", err)
+		}
+
+		if got := string(b); got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	}
+
+	b, err := problem.Marshal(d, problem.WithSnakeCaseExtensions())
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	if got := string(b); got != want {
+		t.Errorf("got %s with snake case extensions, want %s", got, want)
+	}
+}
+
+func TestStrictExtensions(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		d := problem.New("", "Forbidden", http.StatusForbidden, problem.WithExtension("status", http.StatusTeapot))
+
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatalf("failed to marshal details: %s", err)
+		}
+
+		assertJSON(t, `{"status": 403, "title": "Forbidden"}`, b)
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		problem.StrictExtensions = true
+		defer func() {
+			problem.StrictExtensions = false
+		}()
+
+		options := map[string]problem.Option{
+			"WithExtension":    problem.WithExtension("status", http.StatusTeapot),
+			"WithExtensions":   problem.WithExtensions(map[string]any{"title": "Teapot"}),
+			"WithExtensionsIf": problem.WithExtensionsIf(true, map[string]any{"detail": "Teapot"}),
+			"WithKV":           problem.WithKV("instance", "/teapot"),
+		}
+
+		for name, opt := range options {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("no panic was raised for reserved extension key")
+					}
+				}()
+
+				problem.New("", "Forbidden", http.StatusForbidden, opt)
+			})
+		}
+
+		// Non-reserved keys are still allowed.
+		problem.New("", "Forbidden", http.StatusForbidden, problem.WithExtension("balance", 30))
+	})
+}
+
+func TestWithStatusIfUnset(t *testing.T) {
+	unset := (&problem.Details{}).Apply(problem.WithStatusIfUnset(http.StatusInternalServerError))
+
+	if got, want := unset.Status, http.StatusInternalServerError; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+
+	set := (&problem.Details{Status: http.StatusForbidden}).
+		Apply(problem.WithStatusIfUnset(http.StatusInternalServerError))
+
+	if got, want := set.Status, http.StatusForbidden; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+}
+
 type readCloser struct {
 	io.Reader
 	closed bool
@@ -166,6 +574,7 @@ func TestFrom(t *testing.T) {
 		Name           string
 		Type           string
 		Response       string
+		Opts           []problem.FromOption
 		Want           *problem.Details
 		WantBodyClosed bool
 		WantError      bool
@@ -248,6 +657,40 @@ func TestFrom(t *testing.T) {
 			WantBodyClosed: true,
 			WantError:      false,
 		},
+		{
+			Name: "Relative instance with base",
+			Type: problem.ContentType,
+			Response: `{
+				"status": 403,
+				"instance": "msgs/abc"
+			}`,
+			Opts: []problem.FromOption{
+				problem.WithInstanceBase(&url.URL{Scheme: "https", Host: "example.com", Path: "/account/12345/"}),
+			},
+			Want: &problem.Details{
+				Status:   http.StatusForbidden,
+				Instance: "https://example.com/account/12345/msgs/abc",
+			},
+			WantBodyClosed: true,
+			WantError:      false,
+		},
+		{
+			Name: "Absolute instance with base",
+			Type: problem.ContentType,
+			Response: `{
+				"status": 403,
+				"instance": "https://example.org/account/12345/msgs/abc"
+			}`,
+			Opts: []problem.FromOption{
+				problem.WithInstanceBase(&url.URL{Scheme: "https", Host: "example.com", Path: "/account/12345/"}),
+			},
+			Want: &problem.Details{
+				Status:   http.StatusForbidden,
+				Instance: "https://example.org/account/12345/msgs/abc",
+			},
+			WantBodyClosed: true,
+			WantError:      false,
+		},
 	}
 
 	for _, test := range tests {
@@ -259,9 +702,9 @@ func TestFrom(t *testing.T) {
 			resp.Header.Add("Content-Type", test.Type)
 			resp.Body = body
 
-			got, gotErr := problem.From(resp)
+			got, gotErr := problem.From(resp, test.Opts...)
 
-			if diff := cmp.Diff(test.Want, got); diff != "" {
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
 				t.Errorf("From() mismatch (-want +got):\n%s", diff)
 			}
 
@@ -282,38 +725,1460 @@ func TestFrom(t *testing.T) {
 	}
 }
 
-func TestDetails_Unwrap(t *testing.T) {
-	d := &problem.Details{
-		Underlying: testError{},
+func TestFromOrBody(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Type     string
+		Response string
+		Opts     []problem.FromOption
+		Want     *problem.Details
+		WantBody string
+		WantErr  error
+	}{
+		{
+			Name:     "Problem",
+			Type:     problem.ContentType,
+			Response: `{"title": "Teapot"}`,
+			Want:     &problem.Details{Status: http.StatusTeapot, Title: "Teapot"},
+		},
+		{
+			Name:     "No problem",
+			Type:     "text/plain",
+			Response: "I'm a teapot",
+			WantBody: "I'm a teapot",
+		},
+		{
+			Name:     "No problem too large",
+			Type:     "text/plain",
+			Response: "I'm a teapot",
+			Opts:     []problem.FromOption{problem.WithMaxBodySize(4)},
+			WantErr:  problem.ErrBodyTooLarge,
+		},
 	}
 
-	got, want := d.Unwrap(), testError{}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			body := &readCloser{Reader: strings.NewReader(test.Response)}
 
-	if diff := cmp.Diff(want, got); diff != "" {
-		t.Errorf("Details.Unwrap() mismatch (-want +got):\n%s", diff)
+			resp := &http.Response{
+				StatusCode: http.StatusTeapot,
+				Header:     http.Header{"Content-Type": {test.Type}},
+				Body:       body,
+			}
+
+			got, gotBody, err := problem.FromOrBody(resp, test.Opts...)
+			if !errors.Is(err, test.WantErr) {
+				t.Errorf("got error %v, want %v", err, test.WantErr)
+			}
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("FromOrBody() mismatch (-want +got):\n%s", diff)
+			}
+
+			if got, want := string(gotBody), test.WantBody; got != want {
+				t.Errorf("got body %q, want %q", got, want)
+			}
+
+			if !body.closed {
+				t.Error("body was not closed")
+			}
+		})
 	}
+}
 
-	if !errors.Is(d, testError{}) {
-		t.Errorf("errors.Is() returned false, want true")
+type slowReader struct {
+	data  string
+	delay time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if len(s.data) == 0 {
+		return 0, io.EOF
 	}
+
+	time.Sleep(s.delay)
+
+	n := copy(p[:1], s.data)
+	s.data = s.data[n:]
+	return n, nil
 }
 
-func TestDetails_MarshalJSON(t *testing.T) {
+func TestFrom_MaxBodySize(t *testing.T) {
+	const body = `{"type": "https://example.com/probs/out-of-credit", "status": 403}`
+
 	tests := []struct {
-		Name  string
-		Input problem.Details
-		Want  string
+		Name    string
+		Body    string
+		Opts    []problem.FromOption
+		WantErr error
 	}{
 		{
-			Name:  "Empty",
-			Input: problem.Details{},
-			Want:  `{}`,
+			Name: "Default",
+			Body: body,
 		},
 		{
-			Name: "Minimal",
-			Input: problem.Details{
-				Type:   "https://example.com/probs/out-of-credit",
-				Title:  "You do not have enough credit.",
+			Name:    "Default exceeded",
+			Body:    `{"padding": "` + strings.Repeat("x", 1<<20) + `"}`,
+			WantErr: problem.ErrBodyTooLarge,
+		},
+		{
+			Name: "Exact limit",
+			Body: body,
+			Opts: []problem.FromOption{problem.WithMaxBodySize(int64(len(body)))},
+		},
+		{
+			Name:    "Limit exceeded",
+			Body:    body,
+			Opts:    []problem.FromOption{problem.WithMaxBodySize(int64(len(body) - 1))},
+			WantErr: problem.ErrBodyTooLarge,
+		},
+		{
+			Name: "No limit",
+			Body: `{"padding": "` + strings.Repeat("x", 1<<20) + `"}`,
+			Opts: []problem.FromOption{problem.WithMaxBodySize(-1)},
+		},
+		{
+			Name: "Maximum limit",
+			Body: `{"padding": "` + strings.Repeat("x", 1<<20) + `"}`,
+			Opts: []problem.FromOption{problem.WithMaxBodySize(math.MaxInt64)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			resp := newResponse(http.StatusForbidden, problem.ContentType, strings.NewReader(test.Body))
+
+			got, err := problem.From(resp, test.Opts...)
+
+			if !errors.Is(err, test.WantErr) {
+				t.Fatalf("got error %v, want %v", err, test.WantErr)
+			}
+
+			if (got == nil) != (test.WantErr != nil) {
+				t.Errorf("got details %v with error %v", got, err)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	const body = `{"type": "https://example.com/probs/out-of-credit", "status": 403}`
+
+	t.Run("Slow body", func(t *testing.T) {
+		resp := newResponse(http.StatusForbidden, problem.ContentType, &slowReader{data: body, delay: time.Millisecond})
+
+		got, err := problem.FromContext(context.Background(), resp)
+		if err != nil {
+			t.Fatalf("got error %v, want nil", err)
+		}
+
+		want := &problem.Details{
+			Type:   "https://example.com/probs/out-of-credit",
+			Status: http.StatusForbidden,
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+			t.Errorf("FromContext() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		resp := newResponse(http.StatusForbidden, problem.ContentType, &slowReader{data: body, delay: 5 * time.Millisecond})
+
+		if _, err := problem.FromContext(ctx, resp); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		resp := newResponse(http.StatusForbidden, problem.ContentType, &slowReader{data: body, delay: 0})
+
+		if _, err := problem.FromContext(ctx, resp); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestFromTextResponse(t *testing.T) {
+	tests := []struct {
+		Name string
+		Body string
+		Want *problem.Details
+	}{
+		{
+			Name: "Text",
+			Body: "upstream connect error\n",
+			Want: &problem.Details{
+				Title:  "Bad Gateway",
+				Status: http.StatusBadGateway,
+				Detail: "upstream connect error",
+			},
+		},
+		{
+			Name: "Truncated",
+			Body: strings.Repeat("a", problem.MaxTextDetailLength-1) + "äöü",
+			Want: &problem.Details{
+				Title:  "Bad Gateway",
+				Status: http.StatusBadGateway,
+				Detail: strings.Repeat("a", problem.MaxTextDetailLength-1) + "...",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			body := &readCloser{Reader: strings.NewReader(test.Body)}
+
+			resp := &http.Response{Header: http.Header{}}
+			resp.StatusCode = http.StatusBadGateway
+			resp.Header.Add("Content-Type", "text/plain")
+			resp.Body = body
+
+			got := problem.FromTextResponse(resp)
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("FromTextResponse() mismatch (-want +got):\n%s", diff)
+			}
+
+			if !body.closed {
+				t.Error("body was not closed")
+			}
+		})
+	}
+}
+
+func TestFromOrStatus(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Type    string
+		Body    string
+		Header  http.Header
+		Want    *problem.Details
+		WantErr bool
+	}{
+		{
+			Name: "Problem",
+			Type: problem.ContentType,
+			Body: `{"title": "Upstream unavailable"}`,
+			Want: &problem.Details{
+				Title:  "Upstream unavailable",
+				Status: http.StatusBadGateway,
+			},
+		},
+		{
+			Name: "Empty body",
+			Want: &problem.Details{
+				Title:  "Bad Gateway",
+				Status: http.StatusBadGateway,
+			},
+		},
+		{
+			Name:   "Text with Retry-After",
+			Type:   "text/plain",
+			Body:   "upstream connect error",
+			Header: http.Header{"Retry-After": {"120"}},
+			Want: &problem.Details{
+				Title:  "Bad Gateway",
+				Status: http.StatusBadGateway,
+				Header: http.Header{"Retry-After": {"120"}},
+			},
+		},
+		{
+			Name:    "Invalid problem",
+			Type:    problem.ContentType,
+			Body:    `{`,
+			WantErr: true,
+			Want: &problem.Details{
+				Title:  "Bad Gateway",
+				Status: http.StatusBadGateway,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			body := &readCloser{Reader: strings.NewReader(test.Body)}
+
+			resp := &http.Response{Header: http.Header{}}
+			resp.StatusCode = http.StatusBadGateway
+			resp.Body = body
+
+			for k, vs := range test.Header {
+				resp.Header[k] = vs
+			}
+
+			if test.Type != "" {
+				resp.Header.Set("Content-Type", test.Type)
+			}
+
+			got := problem.FromOrStatus(resp)
+
+			if gotErr := got.Underlying != nil; gotErr != test.WantErr {
+				t.Errorf("got underlying error %v, want error: %t", got.Underlying, test.WantErr)
+			}
+
+			got.Underlying = nil
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("FromOrStatus() mismatch (-want +got):\n%s", diff)
+			}
+
+			if !body.closed {
+				t.Error("body was not closed")
+			}
+		})
+	}
+
+	t.Run("Default for status", func(t *testing.T) {
+		problem.DefaultRegistry.RegisterDefaultForStatus(http.StatusBadGateway, &problem.Type{
+			URI:    "https://example.com/probs/upstream-unavailable",
+			Title:  "The upstream service is unavailable.",
+			Status: http.StatusBadGateway,
+		})
+
+		defer func() {
+			problem.DefaultRegistry = &problem.Registry{}
+		}()
+
+		resp := &http.Response{Header: http.Header{}}
+		resp.StatusCode = http.StatusBadGateway
+		resp.Header.Set("Content-Type", "text/plain")
+		resp.Body = &readCloser{Reader: strings.NewReader("upstream connect error")}
+
+		want := &problem.Details{
+			Type:   "https://example.com/probs/upstream-unavailable",
+			Title:  "The upstream service is unavailable.",
+			Status: http.StatusBadGateway,
+		}
+
+		if diff := cmp.Diff(want, problem.FromOrStatus(resp), ignoreUnexported); diff != "" {
+			t.Errorf("FromOrStatus() mismatch (-want +got):\n%s", diff)
+		}
+
+		if diff := cmp.Diff(want, problem.FromStatus(http.StatusBadGateway), ignoreUnexported); diff != "" {
+			t.Errorf("FromStatus() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestNestedExtensions(t *testing.T) {
+	details := &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"balance":  30.0,
+			"accounts": []any{"/account/12345", "/account/67890"},
+		},
+	}
+
+	b, err := problem.Marshal(details, problem.WithNestedExtensions())
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "You do not have enough credit.",
+		"status": 403,
+		"extensions": {
+			"balance": 30,
+			"accounts": ["/account/12345", "/account/67890"]
+		}
+	}`, b)
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.StatusCode = http.StatusForbidden
+	resp.Header.Add("Content-Type", problem.ContentType)
+	resp.Body = &readCloser{Reader: strings.NewReader(string(b))}
+
+	got, err := problem.From(resp, problem.FromNestedExtensions())
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	if diff := cmp.Diff(details, got, ignoreUnexported); diff != "" {
+		t.Errorf("From() mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("Only dropped extensions", func(t *testing.T) {
+		d := problem.New("", "Forbidden", http.StatusForbidden, problem.WithExtension("status", http.StatusTeapot))
+
+		b, err := problem.Marshal(d, problem.WithNestedExtensions())
+		if err != nil {
+			t.Fatalf("failed to marshal details: %s", err)
+		}
+
+		assertJSON(t, `{"status": 403, "title": "Forbidden"}`, b)
+	})
+}
+
+func TestWithStatus0AsNull(t *testing.T) {
+	details := &problem.Details{Title: "You do not have enough credit."}
+
+	b, err := problem.Marshal(details)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"title": "You do not have enough credit."}`, b)
+
+	b, err = problem.Marshal(details, problem.WithStatus0AsNull())
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"title": "You do not have enough credit.", "status": null}`, b)
+}
+
+func TestWithFloatStatus(t *testing.T) {
+	details := &problem.Details{Status: http.StatusForbidden}
+
+	b, err := problem.Marshal(details)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	if got, want := string(b), `{"status":403}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	b, err = problem.Marshal(details, problem.WithFloatStatus())
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	if got, want := string(b), `{"status":403.0}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTypeURIRewriter(t *testing.T) {
+	problem.TypeURIRewriter = func(uri string) string {
+		return strings.Replace(uri, "https://example.com/", "https://staging.example.com/", 1)
+	}
+
+	defer func() {
+		problem.TypeURIRewriter = nil
+	}()
+
+	details := &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Status: http.StatusForbidden,
+	}
+
+	b, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"type": "https://staging.example.com/probs/out-of-credit", "status": 403}`, b)
+
+	b, err = json.Marshal(&problem.Details{Status: http.StatusForbidden})
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"status": 403}`, b)
+
+	if !problem.Is(details, &problem.Type{URI: "https://example.com/probs/out-of-credit"}) {
+		t.Errorf("Is() returned false, want true")
+	}
+}
+
+func TestDefaultTypeURI(t *testing.T) {
+	problem.DefaultTypeURI = "https://example.com/probs/generic"
+
+	defer func() {
+		problem.DefaultTypeURI = ""
+	}()
+
+	b, err := json.Marshal(&problem.Details{Status: http.StatusForbidden})
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"type": "https://example.com/probs/generic", "status": 403}`, b)
+
+	b, err = json.Marshal(&problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Status: http.StatusForbidden,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{"type": "https://example.com/probs/out-of-credit", "status": 403}`, b)
+}
+
+func TestDetails_CorrelationID(t *testing.T) {
+	d := problem.New(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden,
+		problem.WithCorrelationID("4bf92f3577b34da6a3ce929d0e0e4736"))
+
+	if got, want := d.CorrelationID(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("got correlation ID %q, want %q", got, want)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	var got problem.Details
+
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to unmarshal details: %s", err)
+	}
+
+	if got, want := got.CorrelationID(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("got correlation ID %q after round-trip, want %q", got, want)
+	}
+
+	if got := (&problem.Details{}).CorrelationID(); got != "" {
+		t.Errorf("got correlation ID %q, want empty string", got)
+	}
+}
+
+func TestWithNonFiniteFloatsAsNull(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Value any
+	}{
+		{Name: "NaN", Value: math.NaN()},
+		{Name: "+Inf", Value: math.Inf(1)},
+		{Name: "-Inf float32", Value: float32(math.Inf(-1))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			details := &problem.Details{
+				Extensions: map[string]any{"balance": test.Value},
+			}
+
+			_, err := problem.Marshal(details)
+			if err == nil {
+				t.Fatal("expected error not returned")
+			}
+
+			if !strings.Contains(err.Error(), `"balance"`) {
+				t.Errorf("got error %q, want error naming the extension", err)
+			}
+
+			b, err := problem.Marshal(details, problem.WithNonFiniteFloatsAsNull())
+			if err != nil {
+				t.Fatalf("failed to marshal details: %s", err)
+			}
+
+			assertJSON(t, `{"balance": null}`, b)
+		})
+	}
+}
+
+func TestDetails_TypeFragment(t *testing.T) {
+	d := &problem.Details{Type: "https://example.com/probs/out-of-credit#overdraft"}
+
+	if got, want := d.TypeFragment(), "overdraft"; got != want {
+		t.Errorf("got fragment %q, want %q", got, want)
+	}
+
+	d = &problem.Details{Type: "https://example.com/probs/out-of-credit"}
+
+	if got, want := d.TypeFragment(), ""; got != want {
+		t.Errorf("got fragment %q, want %q", got, want)
+	}
+}
+
+func TestDetails_Clone(t *testing.T) {
+	if got := (*problem.Details)(nil).Clone(); got != nil {
+		t.Errorf("got %v for nil details, want nil", got)
+	}
+
+	orig := problem.New(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden,
+		problem.WithExtension("balance", 30),
+		problem.WithHeader("Retry-After", "120"),
+	)
+
+	c := orig.Clone()
+
+	if diff := cmp.Diff(orig, c, ignoreUnexported); diff != "" {
+		t.Errorf("Details.Clone() mismatch (-want +got):\n%s", diff)
+	}
+
+	c.Title = "Changed"
+	c.Extensions["balance"] = 0
+	c.Header.Set("Retry-After", "60")
+
+	if got, want := orig.Title, "You do not have enough credit."; got != want {
+		t.Errorf("got title %q, want %q", got, want)
+	}
+
+	if got, want := orig.Extensions["balance"], 30; got != want {
+		t.Errorf("got balance %v, want %v", got, want)
+	}
+
+	if got, want := orig.Header.Get("Retry-After"), "120"; got != want {
+		t.Errorf("got Retry-After %q, want %q", got, want)
+	}
+}
+
+func TestDetails_Merge(t *testing.T) {
+	baseErr := errors.New("base")
+	otherErr := errors.New("other")
+
+	base := problem.New(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden,
+		problem.WithDetail("Your current balance is 30, but that costs 50."),
+		problem.WithExtension("balance", 30),
+		problem.WithExtension("currency", "EUR"),
+		problem.WithHeader("Retry-After", "120"),
+		problem.WithUnderlying(baseErr),
+	)
+
+	tests := []struct {
+		Name  string
+		Base  *problem.Details
+		Other *problem.Details
+		Want  *problem.Details
+	}{
+		{
+			Name: "Overlay",
+			Base: base,
+			Other: &problem.Details{
+				Instance:   "/account/12345/msgs/abc",
+				Extensions: map[string]any{"balance": 20, "accounts": []string{"/account/12345"}},
+				Header:     http.Header{"Retry-After": {"60"}, "Cache-Control": {"no-store"}},
+			},
+			Want: &problem.Details{
+				Type:     "https://example.com/probs/out-of-credit",
+				Title:    "You do not have enough credit.",
+				Status:   http.StatusForbidden,
+				Detail:   "Your current balance is 30, but that costs 50.",
+				Instance: "/account/12345/msgs/abc",
+				Extensions: map[string]any{
+					"balance":  20,
+					"currency": "EUR",
+					"accounts": []string{"/account/12345"},
+				},
+				Header:     http.Header{"Retry-After": {"60"}, "Cache-Control": {"no-store"}},
+				Underlying: baseErr,
+			},
+		},
+		{
+			Name: "Override fields",
+			Base: base,
+			Other: &problem.Details{
+				Status:     http.StatusPaymentRequired,
+				Title:      "Payment Required",
+				Detail:     "Your current balance is 0.",
+				Underlying: otherErr,
+			},
+			Want: &problem.Details{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "Payment Required",
+				Status:     http.StatusPaymentRequired,
+				Detail:     "Your current balance is 0.",
+				Extensions: map[string]any{"balance": 30, "currency": "EUR"},
+				Header:     http.Header{"Retry-After": {"120"}},
+				Underlying: otherErr,
+			},
+		},
+		{
+			Name:  "Nil other",
+			Base:  base,
+			Other: nil,
+			Want:  base,
+		},
+		{
+			Name:  "Nil base",
+			Base:  nil,
+			Other: base,
+			Want:  base,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := test.Base.Merge(test.Other)
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported, equateUnderlying); diff != "" {
+				t.Errorf("Details.Merge() mismatch (-want +got):\n%s", diff)
+			}
+
+			if got == test.Base || got == test.Other {
+				t.Error("Details.Merge() returned one of its inputs")
+			}
+		})
+	}
+
+	if diff := cmp.Diff(map[string]any{"balance": 30, "currency": "EUR"}, base.Extensions); diff != "" {
+		t.Errorf("base extensions were modified (-want +got):\n%s", diff)
+	}
+}
+
+func TestDetails_Normalize(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Details problem.Details
+		Want    *problem.Details
+	}{
+		{
+			Name:    "Empty type",
+			Details: problem.Details{Title: "Out of credit"},
+			Want:    &problem.Details{Type: problem.AboutBlankTypeURI, Title: "Out of credit"},
+		},
+		{
+			Name:    "Missing title",
+			Details: problem.Details{Type: "https://example.com/probs/not-found", Status: http.StatusNotFound},
+			Want: &problem.Details{
+				Type:   "https://example.com/probs/not-found",
+				Status: http.StatusNotFound,
+				Title:  "Not Found",
+			},
+		},
+		{
+			Name:    "Missing title and status",
+			Details: problem.Details{},
+			Want:    &problem.Details{Type: problem.AboutBlankTypeURI},
+		},
+		{
+			Name: "Float extensions",
+			Details: problem.Details{
+				Type:  problem.AboutBlankTypeURI,
+				Title: "Out of credit",
+				Extensions: map[string]any{
+					"balance":  float64(30),
+					"rate":     1.5,
+					"accounts": []any{float64(12345), "67890"},
+					"limits":   map[string]any{"daily": float64(100)},
+				},
+			},
+			Want: &problem.Details{
+				Type:  problem.AboutBlankTypeURI,
+				Title: "Out of credit",
+				Extensions: map[string]any{
+					"balance":  30,
+					"rate":     1.5,
+					"accounts": []any{12345, "67890"},
+					"limits":   map[string]any{"daily": 100},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			orig := test.Details
+			orig.Extensions = maps.Clone(test.Details.Extensions)
+
+			got := test.Details.Normalize()
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("Details.Normalize() mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(orig, test.Details, ignoreUnexported); diff != "" {
+				t.Errorf("Details.Normalize() modified input (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDetails_Summary(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Details problem.Details
+		Want    string
+	}{
+		{
+			Name: "All",
+			Details: problem.Details{
+				Status: http.StatusForbidden,
+				Title:  "You do not have enough credit.",
+				Detail: "Your current balance is 30, but that costs 50.",
+			},
+			Want: "Your current balance is 30, but that costs 50.",
+		},
+		{
+			Name: "Title and status",
+			Details: problem.Details{
+				Status: http.StatusForbidden,
+				Title:  "You do not have enough credit.",
+			},
+			Want: "You do not have enough credit.",
+		},
+		{
+			Name:    "Status",
+			Details: problem.Details{Status: http.StatusForbidden},
+			Want:    "Forbidden",
+		},
+		{
+			Name:    "Unknown status",
+			Details: problem.Details{Status: 599},
+			Want:    "An unknown error occurred.",
+		},
+		{
+			Name:    "Empty",
+			Details: problem.Details{},
+			Want:    "An unknown error occurred.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := test.Details.Summary(); got != test.Want {
+				t.Errorf("got %q, want %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestDetails_IsClientError(t *testing.T) {
+	tests := []struct {
+		Status     int
+		WantClient bool
+		WantServer bool
+	}{
+		{Status: 0, WantClient: false, WantServer: true},
+		{Status: http.StatusSeeOther, WantClient: false, WantServer: false},
+		{Status: http.StatusBadRequest, WantClient: true, WantServer: false},
+		{Status: http.StatusTeapot, WantClient: true, WantServer: false},
+		{Status: 499, WantClient: true, WantServer: false},
+		{Status: http.StatusInternalServerError, WantClient: false, WantServer: true},
+		{Status: http.StatusServiceUnavailable, WantClient: false, WantServer: true},
+		{Status: 600, WantClient: false, WantServer: false},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.Status), func(t *testing.T) {
+			d := &problem.Details{Status: test.Status}
+
+			if got := d.IsClientError(); got != test.WantClient {
+				t.Errorf("IsClientError() = %t, want %t", got, test.WantClient)
+			}
+
+			if got := d.IsServerError(); got != test.WantServer {
+				t.Errorf("IsServerError() = %t, want %t", got, test.WantServer)
+			}
+		})
+	}
+}
+
+func TestDetails_Headers(t *testing.T) {
+	t.Run("Captured", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.StatusCode = http.StatusTooManyRequests
+		resp.Header.Add("Content-Type", problem.ContentType)
+		resp.Header.Add("Retry-After", "120")
+		resp.Header.Add("X-Request-Id", "abc")
+		resp.Header.Add("X-Ignored", "ignored")
+		resp.Body = &readCloser{Reader: strings.NewReader(`{}`)}
+
+		d, err := problem.From(resp, problem.WithCapturedHeaders("Retry-After", "X-Request-Id"))
+		if err != nil {
+			t.Fatalf("failed to parse details: %s", err)
+		}
+
+		got := d.Headers()
+		want := http.Header{
+			"Retry-After":  {"120"},
+			"X-Request-Id": {"abc"},
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Details.Headers() mismatch (-want +got):\n%s", diff)
+		}
+
+		got.Set("Retry-After", "0")
+
+		if diff := cmp.Diff(want, d.Headers()); diff != "" {
+			t.Errorf("Details.Headers() returned shared header (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if got := (&problem.Details{}).Headers(); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func TestDetails_Apply(t *testing.T) {
+	d := problem.New(
+		"https://example.com/probs/out-of-credit",
+		"You do not have enough credit.",
+		http.StatusForbidden)
+
+	got := d.Apply(
+		problem.WithInstance("/account/12345/msgs/abc"),
+		problem.WithExtension("balance", 30))
+
+	if got != d {
+		t.Errorf("Details.Apply() returned new value")
+	}
+
+	want := &problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"balance": 30,
+		},
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("Details.Apply() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDetails_AsType(t *testing.T) {
+	got := (&problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"currency": "EUR",
+		},
+		Underlying: testError{},
+	}).AsType()
+
+	want := &problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"currency": "EUR",
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Details.AsType() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithStdlibJSON(t *testing.T) {
+	details := &problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"accounts": accountsSlice{12345, 67890},
+			"balance":  30,
+			"currency": "EUR",
+		},
+	}
+
+	tests := []struct {
+		Name string
+		Opts []problem.EncodeOption
+	}{
+		{Name: "Default"},
+		{Name: "Member order", Opts: []problem.EncodeOption{problem.WithMemberOrder([]string{"title", "type"})}},
+		{Name: "Nested extensions", Opts: []problem.EncodeOption{problem.WithNestedExtensions()}},
+		{Name: "Snake case extensions", Opts: []problem.EncodeOption{problem.WithSnakeCaseExtensions()}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			want, err := problem.Marshal(details, test.Opts...)
+			if err != nil {
+				t.Fatalf("failed to marshal details: %s", err)
+			}
+
+			got, err := problem.Marshal(details, append(test.Opts, problem.WithStdlibJSON())...)
+			if err != nil {
+				t.Fatalf("failed to marshal details using encoding/json: %s", err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("Serve", func(t *testing.T) {
+		want := httptest.NewRecorder()
+		problem.Serve(want, httptest.NewRequest(http.MethodGet, "/", nil), details)
+
+		got := httptest.NewRecorder()
+		problem.Serve(got, httptest.NewRequest(http.MethodGet, "/", nil), details, problem.WithStdlibJSON())
+
+		if got.Body.String() != want.Body.String() {
+			t.Errorf("got %s, want %s", got.Body, want.Body)
+		}
+	})
+
+	t.Run("Extension values", func(t *testing.T) {
+		d := &problem.Details{Status: http.StatusForbidden, Extensions: map[string]any{"accounts": []int(nil)}}
+
+		b, err := problem.Marshal(d, problem.WithStdlibJSON())
+		if err != nil {
+			t.Fatalf("failed to marshal details using encoding/json: %s", err)
+		}
+
+		if got, want := string(b), `{"status":403,"accounts":null}`; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+}
+
+func TestWithMemberOrder(t *testing.T) {
+	details := &problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+	}
+
+	b, err := problem.Marshal(details, problem.WithMemberOrder([]string{"title", "detail", "type"}))
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	want := `{` +
+		`"title":"You do not have enough credit.",` +
+		`"detail":"Your current balance is 30, but that costs 50.",` +
+		`"type":"https://example.com/probs/out-of-credit",` +
+		`"status":403,` +
+		`"instance":"/account/12345/msgs/abc"` +
+		`}`
+
+	if got := string(b); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if _, err := problem.Marshal(details, problem.WithMemberOrder([]string{"title", "balance"})); err == nil {
+		t.Errorf("expected error for unknown member not returned")
+	}
+
+	if _, err := problem.Marshal(details, problem.WithMemberOrder([]string{"title", "title"})); err == nil {
+		t.Errorf("expected error for duplicate member not returned")
+	}
+}
+
+func TestWithoutInstance(t *testing.T) {
+	details := &problem.Details{
+		Status:   http.StatusForbidden,
+		Instance: "/account/12345/msgs/abc",
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/account/12345/msgs/abc", nil)
+	rec := httptest.NewRecorder()
+
+	problem.Serve(rec, r, details, problem.WithoutInstance())
+
+	assertResponse(t, rec, http.StatusForbidden, `{"status": 403}`)
+
+	if got, want := details.Instance, "/account/12345/msgs/abc"; got != want {
+		t.Errorf("got instance %q, want %q", got, want)
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Type      string
+		Body      string
+		Want      *problem.Details
+		WantError bool
+	}{
+		{
+			Name: "No problem",
+			Type: "application/json",
+			Body: `ignored`,
+		},
+		{
+			Name:      "Invalid body",
+			Type:      problem.ContentType,
+			Body:      `invalid`,
+			WantError: true,
+		},
+		{
+			Name: "Valid body",
+			Type: problem.ContentType + ";a=1;b=2",
+			Body: `{
+				"type": "https://example.com/probs/out-of-credit",
+				"status": 403,
+				"balance": 30
+			}`,
+			Want: &problem.Details{
+				Type:   "https://example.com/probs/out-of-credit",
+				Status: http.StatusForbidden,
+				Extensions: map[string]any{
+					"balance": 30.0,
+				},
+			},
+		},
+		{
+			Name: "Valid body with no status",
+			Type: problem.ContentType,
+			Body: `{
+				"type": "https://example.com/probs/out-of-credit"
+			}`,
+			Want: &problem.Details{
+				Type:   "https://example.com/probs/out-of-credit",
+				Status: http.StatusTeapot,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, gotErr := problem.ParseString(test.Type, test.Body, http.StatusTeapot)
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("ParseString() mismatch (-want +got):\n%s", diff)
+			}
+
+			switch {
+			case gotErr != nil && !test.WantError:
+				t.Errorf("got error %v, want nil", gotErr)
+			case gotErr == nil && test.WantError:
+				t.Errorf("expected error not returned")
+			}
+		})
+	}
+}
+
+func TestWithJSONNumbers(t *testing.T) {
+	const body = `{
+		"status": 403,
+		"title": "Forbidden",
+		"id": 9007199254740993,
+		"balance": 30.5,
+		"related": [9007199254740995, {"id": 9007199254740997}]
+	}`
+
+	d, err := problem.ParseString(problem.ContentType, body, 0, problem.WithJSONNumbers())
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	wantDetails := &problem.Details{
+		Status: http.StatusForbidden,
+		Title:  "Forbidden",
+		Extensions: map[string]any{
+			"id":      json.Number("9007199254740993"),
+			"balance": json.Number("30.5"),
+			"related": []any{
+				json.Number("9007199254740995"),
+				map[string]any{"id": json.Number("9007199254740997")},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(wantDetails, d, ignoreUnexported); diff != "" {
+		t.Errorf("ParseString() mismatch (-want +got):\n%s", diff)
+	}
+
+	b, err := problem.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	const want = `{"status":403,"title":"Forbidden","balance":30.5,"id":9007199254740993,` +
+		`"related":[9007199254740995,{"id":9007199254740997}]}`
+
+	if got := string(b); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	b, err = json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	if got := string(b); got != want {
+		t.Errorf("got %s from json.Marshal, want %s", got, want)
+	}
+
+	t.Run("Accessors", func(t *testing.T) {
+		if got, ok := problem.ExtensionInt(d, "id"); !ok || got != 9007199254740993 {
+			t.Errorf("got ExtensionInt() = %d, %t, want 9007199254740993, true", got, ok)
+		}
+
+		if _, ok := problem.ExtensionInt(d, "balance"); ok {
+			t.Error("got ExtensionInt() = true for fractional number, want false")
+		}
+
+		if got := d.Normalize().Extensions["id"]; got != 9007199254740993 {
+			t.Errorf("got normalized id %#v, want 9007199254740993", got)
+		}
+
+		if got := d.Normalize().Extensions["balance"]; got != json.Number("30.5") {
+			t.Errorf("got normalized balance %#v, want json.Number(\"30.5\")", got)
+		}
+
+		p, err := problem.ParseString(problem.ContentType, `{"partial": {"total": 3, "succeeded": 2, "failed": 1}}`, 0,
+			problem.WithJSONNumbers())
+		if err != nil {
+			t.Fatalf("failed to parse details: %s", err)
+		}
+
+		want := problem.PartialResults{Total: 3, Succeeded: 2, Failed: 1}
+
+		if got, ok := p.PartialResults(); !ok || got != want {
+			t.Errorf("got PartialResults() = %+v, %t, want %+v, true", got, ok, want)
+		}
+	})
+
+	for name, body := range map[string]string{
+		"Trailing data":     `{"status": 403} {}`,
+		"Duplicate members": `{"id": 1, "id": 2}`,
+		"Invalid UTF-8":     "{\"id\": \"\xff\"}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := problem.ParseString(problem.ContentType, body, 0, problem.WithJSONNumbers())
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	var d problem.Details
+
+	if err := jsonv2.Unmarshal([]byte(`{"status": 403, "id": 9007199254740993}`), &d, problem.JSONNumbers()); err != nil {
+		t.Fatalf("failed to unmarshal details: %s", err)
+	}
+
+	want := &problem.Details{
+		Status:     http.StatusForbidden,
+		Extensions: map[string]any{"id": json.Number("9007199254740993")},
+	}
+
+	if diff := cmp.Diff(want, &d, ignoreUnexported); diff != "" {
+		t.Errorf("json.Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithIgnoredMembers(t *testing.T) {
+	const body = `{
+		"type": "https://example.com/probs/out-of-credit",
+		"status": "403",
+		"title": ["You do not have enough credit."],
+		"detail": "Your current balance is 30, but that costs 50.",
+		"instance": 12345,
+		"balance": 30
+	}`
+
+	got, err := problem.ParseString(problem.ContentType, body, http.StatusTeapot, problem.WithIgnoredMembers())
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	want := &problem.Details{
+		Type:       "https://example.com/probs/out-of-credit",
+		Status:     http.StatusTeapot,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Extensions: map[string]any{"balance": 30.0},
+		IgnoredMembers: map[string]any{
+			"status":   "403",
+			"title":    []any{"You do not have enough credit."},
+			"instance": 12345.0,
+		},
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("ParseString() mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = problem.ParseString(problem.ContentType, body, http.StatusTeapot)
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	if got.IgnoredMembers != nil {
+		t.Errorf("got ignored members %v without option, want nil", got.IgnoredMembers)
+	}
+}
+
+func TestWithRetryAfter(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Option   problem.Option
+		Expected string
+	}{
+		{
+			Name:     "Seconds",
+			Option:   problem.WithRetryAfter(2 * time.Minute),
+			Expected: "120",
+		},
+		{
+			Name:     "Fractional seconds",
+			Option:   problem.WithRetryAfter(1500 * time.Millisecond),
+			Expected: "2",
+		},
+		{
+			Name:     "Negative",
+			Option:   problem.WithRetryAfter(-time.Second),
+			Expected: "0",
+		},
+		{
+			Name:     "Time",
+			Option:   problem.WithRetryAfterTime(time.Date(2015, 10, 21, 9, 28, 0, 0, time.FixedZone("CEST", 7200))),
+			Expected: "Wed, 21 Oct 2015 07:28:00 GMT",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			d := problem.New("", "Too Many Requests", http.StatusTooManyRequests, test.Option)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			d.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Retry-After"); got != test.Expected {
+				t.Errorf("got Retry-After %q, want %q", got, test.Expected)
+			}
+
+			assertResponse(t, w, http.StatusTooManyRequests, `{
+				"status": 429,
+				"title": "Too Many Requests"
+			}`)
+		})
+	}
+}
+
+func TestDetails_RetryAfter(t *testing.T) {
+	parse := func(t *testing.T, retryAfter string) *problem.Details {
+		t.Helper()
+
+		resp := newResponse(http.StatusServiceUnavailable, problem.ContentType,
+			strings.NewReader(`{"title": "Service Unavailable"}`))
+		resp.Header.Set("Retry-After", retryAfter)
+
+		d, err := problem.From(resp)
+		if err != nil {
+			t.Fatalf("failed to parse details: %s", err)
+		}
+
+		return d
+	}
+
+	t.Run("Delay seconds", func(t *testing.T) {
+		d := parse(t, "120")
+
+		got, ok := d.RetryAfter()
+		if got != 2*time.Minute || !ok {
+			t.Errorf("got %s, %t, want %s, true", got, ok, 2*time.Minute)
+		}
+	})
+
+	t.Run("HTTP date", func(t *testing.T) {
+		d := parse(t, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+
+		got, ok := d.RetryAfter()
+		if got <= 59*time.Minute || got > time.Hour || !ok {
+			t.Errorf("got %s, %t, want about %s, true", got, ok, time.Hour)
+		}
+	})
+
+	t.Run("HTTP date in the past", func(t *testing.T) {
+		d := parse(t, "Wed, 21 Oct 2015 07:28:00 GMT")
+
+		got, ok := d.RetryAfter()
+		if got != 0 || !ok {
+			t.Errorf("got %s, %t, want 0s, true", got, ok)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		d := parse(t, "soon")
+
+		if got, ok := d.RetryAfter(); ok {
+			t.Errorf("got %s, true, want false", got)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		if got, ok := (&problem.Details{}).RetryAfter(); ok {
+			t.Errorf("got %s, true, want false", got)
+		}
+	})
+}
+
+func TestFromBytes(t *testing.T) {
+	body := []byte(`{"type": "https://example.com/probs/out-of-credit", "title": "You do not have enough credit."}`)
+
+	got, err := problem.FromBytes("application/json", body)
+	if got != nil || err != nil {
+		t.Errorf("got %v, %v, want nil, nil", got, err)
+	}
+
+	got, err = problem.FromBytes(problem.ContentType+"; charset=utf-8", body)
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	want := &problem.Details{
+		Type:  "https://example.com/probs/out-of-credit",
+		Title: "You do not have enough credit.",
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("FromBytes() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := problem.FromBytes(problem.ContentType, []byte(`invalid`)); err == nil {
+		t.Errorf("got nil error for invalid body, want error")
+	}
+}
+
+func TestDetails_Unwrap(t *testing.T) {
+	d := &problem.Details{
+		Underlying: testError{},
+	}
+
+	got, want := d.Unwrap(), testError{}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Details.Unwrap() mismatch (-want +got):\n%s", diff)
+	}
+
+	if !errors.Is(d, testError{}) {
+		t.Errorf("errors.Is() returned false, want true")
+	}
+}
+
+func TestDetails_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input problem.Details
+		Want  string
+	}{
+		{
+			Name:  "Empty",
+			Input: problem.Details{},
+			Want:  `{}`,
+		},
+		{
+			Name: "Minimal",
+			Input: problem.Details{
+				Type:   "https://example.com/probs/out-of-credit",
+				Title:  "You do not have enough credit.",
 				Status: http.StatusForbidden,
 			},
 			Want: `{
@@ -402,6 +2267,13 @@ func TestDetails_MarshalJSON(t *testing.T) {
 			}
 
 			assertJSON(t, test.Want, actualJSON)
+
+			stdlibJSON, err := problem.Marshal(&test.Input, problem.WithStdlibJSON())
+			if err != nil {
+				t.Fatalf("failed to marshal input using encoding/json: %s", err)
+			}
+
+			assertJSON(t, test.Want, stdlibJSON)
 		})
 	}
 }
@@ -474,7 +2346,7 @@ func TestDetails_UnmarshalJSON(t *testing.T) {
 				t.Fatalf("failed to unmarshal input: %s", err)
 			}
 
-			if diff := cmp.Diff(test.Want, got); diff != "" {
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
 				t.Errorf("unmarshaled details mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -551,14 +2423,566 @@ func TestDetails_ServeHTTP(t *testing.T) {
 			},
 		}).ServeHTTP(rec, r)
 
-		assertResponse(t, rec, http.StatusForbidden, `{
-			"type": "https://example.com/probs/out-of-credit",
-			"title": "You do not have enough credit.",
-			"status": 403,
-			"detail": "Your current balance is 30, but that costs 50.",
-			"instance": "/account/12345/msgs/abc",
-			"balance": 30,
-			"accounts": ["/account/12345", "/account/67890"]
+		assertResponse(t, rec, http.StatusForbidden, `{
+			"type": "https://example.com/probs/out-of-credit",
+			"title": "You do not have enough credit.",
+			"status": 403,
+			"detail": "Your current balance is 30, but that costs 50.",
+			"instance": "/account/12345/msgs/abc",
+			"balance": 30,
+			"accounts": ["/account/12345", "/account/67890"]
+		}`)
+	})
+}
+
+func TestDetails_Write(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Length", "1337")
+		rec.Header().Set("Content-Type", "application/xml")
+
+		err := (&problem.Details{
+			Type:   "https://example.com/probs/out-of-credit",
+			Title:  "You do not have enough credit.",
+			Status: http.StatusForbidden,
+		}).Write(rec)
+		if err != nil {
+			t.Fatalf("got error %v, want nil", err)
+		}
+
+		assertResponse(t, rec, http.StatusForbidden, `{
+			"type": "https://example.com/probs/out-of-credit",
+			"title": "You do not have enough credit.",
+			"status": 403
+		}`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		err := (&problem.Details{
+			Status:     http.StatusForbidden,
+			Extensions: map[string]any{"balance": math.NaN()},
+		}).Write(rec)
+		if err == nil {
+			t.Fatal("got nil error, want error")
+		}
+
+		if rec.Body.Len() > 0 {
+			t.Errorf("data was written")
+		}
+	})
+}
+
+func TestWithContentLength(t *testing.T) {
+	details := &problem.Details{Status: http.StatusForbidden}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Length", "1337")
+
+	problem.Serve(rec, r, details)
+
+	assertResponse(t, rec, http.StatusForbidden, `{"status": 403}`)
+
+	rec = httptest.NewRecorder()
+	rec.Header().Set("Content-Length", "1337")
+
+	problem.Serve(rec, r, details, problem.WithContentLength())
+
+	if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(rec.Body.Len()); got != want {
+		t.Errorf("got Content-Length %s, want %s", got, want)
+	}
+}
+
+func TestWithStreaming(t *testing.T) {
+	details := problem.New("", "Forbidden", http.StatusForbidden,
+		problem.WithExtension("accounts", []string{"/account/12345", "/account/67890"}),
+		problem.WithExtension("balance", 30))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	want := httptest.NewRecorder()
+	problem.Serve(want, r, details, problem.WithContentLength())
+
+	got := httptest.NewRecorder()
+	problem.Serve(got, r, details, problem.WithContentLength(), problem.WithStreaming())
+
+	if diff := cmp.Diff(want.Body.String(), got.Body.String()); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(want.Header(), got.Header()); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		details := &problem.Details{
+			Status:     http.StatusForbidden,
+			Extensions: map[string]any{"balance": math.NaN()},
+		}
+
+		rec := httptest.NewRecorder()
+
+		if err := details.Write(rec, problem.WithStreaming()); err == nil {
+			t.Error("expected error, got nil")
+		}
+
+		if rec.Body.Len() > 0 || rec.Header().Get("Content-Type") != "" {
+			t.Errorf("data was written")
+		}
+	})
+}
+
+func TestWithContentTypeSuffix(t *testing.T) {
+	details := &problem.Details{Status: http.StatusForbidden}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	problem.Serve(rec, r, details, problem.WithContentTypeSuffix("vnd.acme.problem"))
+
+	const contentType = "application/vnd.acme.problem+json"
+
+	if got, want := rec.Header().Get("Content-Type"), contentType; got != want {
+		t.Errorf("got Content-Type %q, want %q", got, want)
+	}
+
+	got, err := problem.Parse(contentType, rec.Body.Bytes(), http.StatusTeapot)
+	if err != nil || got != nil {
+		t.Errorf("got %v, %v, want nil, nil", got, err)
+	}
+
+	got, err = problem.Parse(contentType, rec.Body.Bytes(), http.StatusTeapot, problem.WithLenientContentType())
+	if err != nil {
+		t.Fatalf("failed to parse details: %s", err)
+	}
+
+	if diff := cmp.Diff(details, got, ignoreUnexported); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithLenientContentType(t *testing.T) {
+	tests := []struct {
+		ContentType string
+		Accepted    bool
+	}{
+		{ContentType: problem.ContentType, Accepted: true},
+		{ContentType: "application/vnd.acme.problem+json; charset=utf-8", Accepted: true},
+		{ContentType: "application/json", Accepted: false},
+		{ContentType: "text/vnd.acme+json", Accepted: false},
+		{ContentType: "invalid;", Accepted: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ContentType, func(t *testing.T) {
+			got, err := problem.ParseString(test.ContentType, `{"status":403}`, 0, problem.WithLenientContentType())
+			if err != nil {
+				t.Fatalf("failed to parse details: %s", err)
+			}
+
+			if accepted := got != nil; accepted != test.Accepted {
+				t.Errorf("got accepted %t, want %t", accepted, test.Accepted)
+			}
+		})
+	}
+}
+
+func TestWithExposedDetail(t *testing.T) {
+	details := &problem.Details{
+		Status:     http.StatusInternalServerError,
+		Underlying: errors.New("database connection lost"),
+	}
+
+	tests := []struct {
+		Name   string
+		Global bool
+		Opts   []problem.EncodeOption
+		Want   string
+	}{
+		{
+			Name: "Disabled",
+			Want: `{"status": 500}`,
+		},
+		{
+			Name: "Option",
+			Opts: []problem.EncodeOption{problem.WithExposedDetail()},
+			Want: `{"status": 500, "detail": "database connection lost"}`,
+		},
+		{
+			Name:   "Global",
+			Global: true,
+			Want:   `{"status": 500, "detail": "database connection lost"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			problem.ExposeDetail = test.Global
+
+			defer func() {
+				problem.ExposeDetail = false
+			}()
+
+			b, err := problem.Marshal(details, test.Opts...)
+			if err != nil {
+				t.Fatalf("failed to marshal details: %s", err)
+			}
+
+			assertJSON(t, test.Want, b)
+
+			if details.Detail != "" {
+				t.Errorf("details were modified")
+			}
+		})
+	}
+}
+
+func TestWithSanitizedText(t *testing.T) {
+	details := &problem.Details{
+		Title:  "You do not have\tenough credit.",
+		Detail: "Your current balance is 30,\nbut that costs 50.\x00",
+	}
+
+	b, err := problem.Marshal(details)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"title": "You do not have\tenough credit.",
+		"detail": "Your current balance is 30,\nbut that costs 50.\u0000"
+	}`, b)
+
+	b, err = problem.Marshal(details, problem.WithSanitizedText())
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"title": "You do not have enough credit.",
+		"detail": "Your current balance is 30, but that costs 50."
+	}`, b)
+}
+
+func TestStatusFromTitle(t *testing.T) {
+	statuses := map[string]int{
+		"You do not have enough credit.": http.StatusForbidden,
+	}
+
+	t.Run("Mapped", func(t *testing.T) {
+		details := &problem.Details{Title: "You do not have enough credit."}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		problem.Serve(rec, r, details, problem.StatusFromTitle(statuses))
+
+		assertResponse(t, rec, http.StatusForbidden, `{
+			"title": "You do not have enough credit.",
+			"status": 403
+		}`)
+
+		if details.Status != 0 {
+			t.Errorf("details were modified")
+		}
+	})
+
+	t.Run("Unmapped", func(t *testing.T) {
+		b, err := problem.Marshal(&problem.Details{Title: "Your account is locked."}, problem.StatusFromTitle(statuses))
+		if err != nil {
+			t.Fatalf("failed to marshal details: %s", err)
+		}
+
+		assertJSON(t, `{"title": "Your account is locked."}`, b)
+	})
+}
+
+func TestWithSnakeCaseExtensions(t *testing.T) {
+	details := &problem.Details{
+		Title: "Your request is not valid.",
+		Extensions: map[string]any{
+			"invalidParams":  []string{"age"},
+			"HTTPStatusCode": 400,
+			"request_id":     "abc",
+			"invalid-names":  []string{"color"},
+			"Title":          "Overridden",
+		},
+	}
+
+	b, err := problem.Marshal(details, problem.WithSnakeCaseExtensions())
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"title": "Your request is not valid.",
+		"invalid_params": ["age"],
+		"http_status_code": 400,
+		"request_id": "abc",
+		"invalid-names": ["color"]
+	}`, b)
+
+	t.Run("Colliding keys", func(t *testing.T) {
+		details := &problem.Details{
+			Status: http.StatusBadRequest,
+			Title:  "Your request is not valid.",
+			Extensions: map[string]any{
+				"fooBar":  1,
+				"foo_bar": 2,
+				"FooBar":  3,
+				"bazQux":  4,
+				"BazQux":  5,
+			},
+		}
+
+		const want = `{
+			"status": 400,
+			"title": "Your request is not valid.",
+			"baz_qux": 5,
+			"foo_bar": 2
+		}`
+
+		b, err := problem.Marshal(details, problem.WithSnakeCaseExtensions())
+		if err != nil {
+			t.Fatalf("failed to marshal details: %s", err)
+		}
+
+		assertJSON(t, want, b)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		problem.Serve(w, r, details, problem.WithSnakeCaseExtensions(), problem.WithoutInstance())
+
+		assertResponse(t, w, http.StatusBadRequest, want)
+	})
+}
+
+func TestDetails_HandlerFunc(t *testing.T) {
+	d := &problem.Details{
+		Type:   "https://example.com/not-found",
+		Status: http.StatusNotFound,
+		Title:  "Not Found",
+	}
+
+	h := d.HandlerFunc(problem.WithoutInstance())
+
+	for _, path := range []string{"/", "/foo", "/foo/bar"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+
+			h.ServeHTTP(w, r)
+
+			assertResponse(t, w, http.StatusNotFound, `{
+				"type": "https://example.com/not-found",
+				"status": 404,
+				"title": "Not Found"
+			}`)
+		})
+	}
+}
+
+func TestMarshal_UTF8(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Details *problem.Details
+		Want    string
+	}{
+		{
+			Name: "Multibyte",
+			Details: &problem.Details{
+				Title:  "Sie haben nicht genügend Guthaben.",
+				Detail: "残高が不足しています 💸",
+			},
+			Want: `{"title":"Sie haben nicht genügend Guthaben.","detail":"残高が不足しています 💸"}`,
+		},
+		{
+			Name: "Invalid",
+			Details: &problem.Details{
+				Title:      "Invalid \xff title",
+				Detail:     "Invalid \xe2\x82 detail",
+				Extensions: map[string]any{"reason": "invalid \xff"},
+			},
+			Want: "{\"title\":\"Invalid \ufffd title\",\"detail\":\"Invalid \ufffd detail\",\"reason\":\"invalid \ufffd\"}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+
+			test.Details.ServeHTTP(rec, r)
+
+			body := rec.Body.Bytes()
+
+			if bytes.HasPrefix(body, []byte("\xef\xbb\xbf")) {
+				t.Errorf("body starts with BOM")
+			}
+
+			if !utf8.Valid(body) {
+				t.Errorf("body is not valid UTF-8")
+			}
+
+			if got := string(body); got != test.Want {
+				t.Errorf("got %s, want %s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestWithMeta(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+
+	d := problem.New("", "Not Found", http.StatusNotFound,
+		problem.WithMeta("logger", logger),
+		problem.WithMeta("user_id", 12345))
+
+	if got := d.Meta("logger"); got != logger {
+		t.Errorf("got logger %v, want %v", got, logger)
+	}
+
+	if got, want := d.Meta("user_id"), 12345; got != want {
+		t.Errorf("got user_id %v, want %v", got, want)
+	}
+
+	if got := d.Meta("missing"); got != nil {
+		t.Errorf("got missing %v, want nil", got)
+	}
+
+	if d.Extensions != nil {
+		t.Errorf("got extensions %v, want nil", d.Extensions)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"status": 404,
+		"title": "Not Found"
+	}`, b)
+}
+
+func TestWithLocation(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/account/12345/msgs", nil)
+	rec := httptest.NewRecorder()
+
+	problem.New(
+		"https://example.com/probs/duplicate-message",
+		"The message was already sent.",
+		http.StatusSeeOther,
+		problem.WithLocation("/account/12345/msgs/abc"),
+	).ServeHTTP(rec, r)
+
+	assertResponse(t, rec, http.StatusSeeOther, `{
+		"type": "https://example.com/probs/duplicate-message",
+		"title": "The message was already sent.",
+		"status": 303
+	}`)
+
+	if got, want := rec.Header().Get("Location"), "/account/12345/msgs/abc"; got != want {
+		t.Errorf("got Location %q, want %q", got, want)
+	}
+}
+
+func TestWithTrailer(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	problem.New("", "Internal Server Error", http.StatusInternalServerError,
+		problem.WithTrailer("Server-Timing", "db;dur=53"),
+		problem.WithTrailer("X-Checksum", "abc"),
+	).ServeHTTP(rec, r)
+
+	assertResponse(t, rec, http.StatusInternalServerError, `{
+		"title": "Internal Server Error",
+		"status": 500
+	}`)
+
+	result := rec.Result()
+
+	if diff := cmp.Diff([]string{"Server-Timing", "X-Checksum"}, result.Header.Values("Trailer")); diff != "" {
+		t.Errorf("Trailer header mismatch (-want +got):\n%s", diff)
+	}
+
+	want := http.Header{
+		"Server-Timing": {"db;dur=53"},
+		"X-Checksum":    {"abc"},
+	}
+
+	if diff := cmp.Diff(want, result.Trailer); diff != "" {
+		t.Errorf("trailer mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnMarshalError(t *testing.T) {
+	defer func() {
+		problem.OnMarshalError = nil
+	}()
+
+	details := &problem.Details{
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"balance": math.NaN(),
+		},
+	}
+
+	t.Run("Observe", func(t *testing.T) {
+		var called bool
+
+		problem.OnMarshalError = func(d *problem.Details, err error) *problem.Details {
+			called = true
+
+			if d != details {
+				t.Errorf("got details %v, want %v", d, details)
+			}
+
+			if err == nil {
+				t.Errorf("got nil error")
+			}
+
+			return nil
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic was raised")
+			}
+
+			if !called {
+				t.Error("OnMarshalError was not called")
+			}
+
+			if rec.Body.Len() > 0 {
+				t.Errorf("data was written")
+			}
+		}()
+
+		details.ServeHTTP(rec, r)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		problem.OnMarshalError = func(*problem.Details, error) *problem.Details {
+			return problem.InternalServerError
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		details.ServeHTTP(rec, r)
+
+		assertResponse(t, rec, http.StatusInternalServerError, `{
+			"status": 500,
+			"title": "Internal Server Error"
 		}`)
 	})
 }
@@ -724,6 +3148,122 @@ func TestIs(t *testing.T) {
 			},
 			Want: true,
 		},
+		{
+			Name: "Type with fragment",
+			Error: &problem.Details{
+				Type: "https://example.com/probs/out-of-credit#overdraft",
+			},
+			Type: problem.Type{
+				URI:      "https://example.com/probs/out-of-credit",
+				Fragment: "overdraft",
+			},
+			Want: true,
+		},
+		{
+			Name: "Type with mismatched fragment",
+			Error: &problem.Details{
+				Type: "https://example.com/probs/out-of-credit#overdraft",
+			},
+			Type: problem.Type{
+				URI:      "https://example.com/probs/out-of-credit",
+				Fragment: "limit",
+			},
+			Want: false,
+		},
+		{
+			Name: "Type with fragment and no fragment in problem",
+			Error: &problem.Details{
+				Type: "https://example.com/probs/out-of-credit",
+			},
+			Type: problem.Type{
+				URI:      "https://example.com/probs/out-of-credit",
+				Fragment: "overdraft",
+			},
+			Want: false,
+		},
+		{
+			Name: "Type without fragment and fragment in problem",
+			Error: &problem.Details{
+				Type: "https://example.com/probs/out-of-credit#overdraft",
+			},
+			Type: problem.Type{
+				URI: "https://example.com/probs/out-of-credit",
+			},
+			Want: false,
+		},
+		{
+			Name: "Type with about:blank URI matches about:blank type",
+			Error: &problem.Details{
+				Type: problem.AboutBlankTypeURI,
+			},
+			Type: problem.Type{
+				URI: problem.AboutBlankTypeURI,
+			},
+			Want: true,
+		},
+		{
+			Name: "Type with about:blank URI and title matches empty type",
+			Error: &problem.Details{
+				Title: "Not Found",
+			},
+			Type: problem.Type{
+				URI:   problem.AboutBlankTypeURI,
+				Title: "Not Found",
+			},
+			Want: true,
+		},
+		{
+			Name: "Type with empty URI matches about:blank type",
+			Error: &problem.Details{
+				Type:  problem.AboutBlankTypeURI,
+				Title: "Not Found",
+			},
+			Type: problem.Type{
+				Title: "Not Found",
+			},
+			Want: true,
+		},
+		{
+			Name: "Type with about:blank URI does not match other type",
+			Error: &problem.Details{
+				Type: "https://example.com/probs/out-of-credit",
+			},
+			Type: problem.Type{
+				URI: problem.AboutBlankTypeURI,
+			},
+			Want: false,
+		},
+		{
+			Name: "Status in set",
+			Error: &problem.Details{
+				Status: http.StatusForbidden,
+			},
+			Type: problem.Type{
+				Statuses: []int{http.StatusUnauthorized, http.StatusForbidden},
+			},
+			Want: true,
+		},
+		{
+			Name: "Status not in set",
+			Error: &problem.Details{
+				Status: http.StatusNotFound,
+			},
+			Type: problem.Type{
+				Statuses: []int{http.StatusUnauthorized, http.StatusForbidden},
+			},
+			Want: false,
+		},
+		{
+			Name: "Status takes precedence over set",
+			Error: &problem.Details{
+				Status: http.StatusForbidden,
+			},
+			Type: problem.Type{
+				Status:   http.StatusUnauthorized,
+				Statuses: []int{http.StatusUnauthorized, http.StatusForbidden},
+			},
+			Want: false,
+		},
 	}
 
 	for _, test := range tests {
@@ -735,6 +3275,121 @@ func TestIs(t *testing.T) {
 	}
 }
 
+func TestIsExact(t *testing.T) {
+	typ := &problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"code":     "OUT_OF_CREDIT",
+			"accounts": []string{"/account/12345"},
+		},
+	}
+
+	tests := []struct {
+		Name  string
+		Error error
+		Type  *problem.Type
+		Want  bool
+	}{
+		{
+			Name:  "Not details",
+			Error: io.EOF,
+			Type:  typ,
+			Want:  false,
+		},
+		{
+			Name:  "Matching extensions",
+			Error: typ.Details(problem.WithExtension("balance", 30)),
+			Type:  typ,
+			Want:  true,
+		},
+		{
+			Name:  "Wrapped",
+			Error: fmt.Errorf("wrapped: %w", typ.Details()),
+			Type:  typ,
+			Want:  true,
+		},
+		{
+			Name:  "Different extension value",
+			Error: typ.Details(problem.WithExtension("code", "OVERDRAFT")),
+			Type:  typ,
+			Want:  false,
+		},
+		{
+			Name:  "Different extension type",
+			Error: typ.Details(problem.WithExtension("accounts", []any{"/account/12345"})),
+			Type:  typ,
+			Want:  false,
+		},
+		{
+			Name: "Missing extension",
+			Error: &problem.Details{
+				Type:       "https://example.com/probs/out-of-credit",
+				Status:     http.StatusForbidden,
+				Extensions: map[string]any{"code": "OUT_OF_CREDIT"},
+			},
+			Type: typ,
+			Want: false,
+		},
+		{
+			Name:  "Different status",
+			Error: typ.Details(problem.WithStatus(http.StatusPaymentRequired)),
+			Type:  typ,
+			Want:  false,
+		},
+		{
+			Name:  "Type without extensions",
+			Error: &problem.Details{Type: "https://example.com/probs/out-of-credit", Status: http.StatusForbidden},
+			Type:  &problem.Type{URI: "https://example.com/probs/out-of-credit"},
+			Want:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if got := problem.IsExact(test.Error, test.Type); got != test.Want {
+				t.Errorf("got %t, want %t", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestTypeURI(t *testing.T) {
+	tests := []struct {
+		Base string
+		Slug string
+		Want string
+	}{
+		{Base: "https://example.com/probs", Slug: "out-of-credit", Want: "https://example.com/probs/out-of-credit"},
+		{Base: "https://example.com/probs/", Slug: "out-of-credit", Want: "https://example.com/probs/out-of-credit"},
+		{Base: "https://example.com/probs", Slug: "/out-of-credit", Want: "https://example.com/probs/out-of-credit"},
+		{Base: "https://example.com/probs//", Slug: "//out-of-credit", Want: "https://example.com/probs/out-of-credit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Base+" "+test.Slug, func(t *testing.T) {
+			if got := problem.TypeURI(test.Base, test.Slug); got != test.Want {
+				t.Errorf("got %q, want %q", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestNewType(t *testing.T) {
+	got := problem.NewType("https://example.com/probs/", "out-of-credit",
+		"You do not have enough credit.", http.StatusForbidden)
+
+	want := &problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewType() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestType_Details(t *testing.T) {
 	got := (&problem.Type{
 		URI:    "https://example.com/probs/out-of-credit",
@@ -767,7 +3422,165 @@ func TestType_Details(t *testing.T) {
 		},
 	}
 
-	if diff := cmp.Diff(want, got); diff != "" {
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("Type.Details() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestType_Details_StickyExtensions(t *testing.T) {
+	got := (&problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"currency": "EUR",
+			"doc_url":  "https://example.com/docs/out-of-credit",
+		},
+		StickyExtensions: []string{"doc_url"},
+	}).Details(
+		problem.WithExtension("currency", "USD"),
+		problem.WithExtension("doc_url", "https://example.com/docs/other"),
+	)
+
+	want := &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"currency": "USD",
+			"doc_url":  "https://example.com/docs/out-of-credit",
+		},
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
 		t.Errorf("Type.Details() mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestType_Minimal(t *testing.T) {
+	got := (&problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"currency": "EUR",
+		},
+		StickyExtensions: []string{"currency"},
+	}).Minimal()
+
+	want := &problem.Details{
+		Type:   "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("Type.Minimal() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestType_Wrap(t *testing.T) {
+	typ := &problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Extensions: map[string]any{
+			"currency": "EUR",
+		},
+	}
+
+	tests := []struct {
+		Name    string
+		Type    *problem.Type
+		Details *problem.Details
+		Want    *problem.Details
+		WantErr string
+	}{
+		{
+			Name: "Consistent",
+			Details: &problem.Details{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "Not enough credit.",
+				Status:     http.StatusForbidden,
+				Extensions: map[string]any{"currency": "USD"},
+			},
+			Want: &problem.Details{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "Not enough credit.",
+				Status:     http.StatusForbidden,
+				Extensions: map[string]any{"currency": "USD"},
+			},
+		},
+		{
+			Name: "Fillable",
+			Details: &problem.Details{
+				Detail: "Your current balance is 30, but that costs 50.",
+			},
+			Want: &problem.Details{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "You do not have enough credit.",
+				Status:     http.StatusForbidden,
+				Detail:     "Your current balance is 30, but that costs 50.",
+				Extensions: map[string]any{"currency": "EUR"},
+			},
+		},
+		{
+			Name: "Conflicting",
+			Details: &problem.Details{
+				Type:   "https://example.com/probs/account-locked",
+				Status: http.StatusConflict,
+			},
+			WantErr: `problem: type "https://example.com/probs/account-locked" does not match ` +
+				`"https://example.com/probs/out-of-credit"` + "\n" +
+				`problem: status 409 does not match 403 for type "https://example.com/probs/out-of-credit"`,
+		},
+		{
+			Name:    "Type without status",
+			Type:    &problem.Type{URI: "https://example.com/probs/not-found"},
+			Details: &problem.Details{Status: http.StatusNotFound},
+			Want: &problem.Details{
+				Type:   "https://example.com/probs/not-found",
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			Name:    "Type without URI",
+			Type:    &problem.Type{Status: http.StatusNotFound},
+			Details: &problem.Details{Type: problem.AboutBlankTypeURI, Status: http.StatusNotFound},
+			Want: &problem.Details{
+				Type:   problem.AboutBlankTypeURI,
+				Status: http.StatusNotFound,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			typ := typ
+			if test.Type != nil {
+				typ = test.Type
+			}
+
+			got, err := typ.Wrap(test.Details)
+
+			if test.WantErr != "" {
+				if err == nil || err.Error() != test.WantErr {
+					t.Errorf("got error %v, want %s", err, test.WantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("got error %v, want nil", err)
+			}
+
+			if got == test.Details {
+				t.Errorf("Type.Wrap() returned input instead of copy")
+			}
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("Type.Wrap() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package problem
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	minStatus = 100
+	maxStatus = 599
+)
+
+// DefaultRegistry is the default [Registry] used by [Describe].
+var DefaultRegistry = &Registry{}
+
+// Describe is a shorthand for DefaultRegistry.Describe(uri, detail). See [Registry.Describe].
+func Describe(uri string, detail string) *Details {
+	return DefaultRegistry.Describe(uri, detail)
+}
+
+// FromStatus is a shorthand for DefaultRegistry.FromStatus(status, opts...). See [Registry.FromStatus].
+func FromStatus(status int, opts ...Option) *Details {
+	return DefaultRegistry.FromStatus(status, opts...)
+}
+
+// Registry is a collection of known problem types.
+//
+// A Registry can be used to keep track of all problem types used by an application, for example to validate them
+// during startup or in tests.
+//
+// The zero value is an empty registry ready for use. A Registry is safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	types      []*Type
+	registered map[*Type]struct{}
+	defaults   map[int]*Type
+
+	// seen contains the types used while tracking was enabled. It is a [sync.Map] so that types can be marked as seen
+	// without taking the exclusive lock.
+	seen sync.Map
+}
+
+var (
+	// trackingEnabled is set as long as at least one registry has tracking enabled, so that [Type.Details] only has
+	// to do a single atomic load as long as tracking is not used.
+	trackingEnabled atomic.Bool
+
+	trackingMu         sync.RWMutex
+	trackingRegistries []*Registry
+)
+
+// Register adds the given types to the registry.
+//
+// Types are not validated when registering. Use [Registry.Validate] to check the registered types.
+func (r *Registry) Register(types ...*Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.types = append(r.types, types...)
+
+	if r.registered == nil {
+		r.registered = make(map[*Type]struct{}, len(types))
+	}
+
+	for _, t := range types {
+		r.registered[t] = struct{}{}
+	}
+}
+
+// Types returns all registered types in the order they were registered.
+func (r *Registry) Types() []*Type {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.types)
+}
+
+// Lookup returns the first registered type with the given URI or nil if no such type was registered.
+//
+// An empty URI is treated as "about:blank". See also [AboutBlankTypeURI].
+func (r *Registry) Lookup(uri string) *Type {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	uri = cmp.Or(uri, AboutBlankTypeURI)
+
+	for _, t := range r.types {
+		if cmp.Or(t.URI, AboutBlankTypeURI) == uri {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// Match returns the registered type matching the type URI of d or nil if there is no such type.
+//
+// It is the same as calling [Registry.Lookup] with d.Type.
+func (r *Registry) Match(d *Details) *Type {
+	return r.Lookup(d.Type)
+}
+
+// From parses the problem from the given response using [From] and returns it together with the registered type
+// matching its type URI, if any.
+//
+// If the response does not contain a problem, From returns nil, nil, nil. If the problem has an unknown type, the
+// returned type is nil.
+func (r *Registry) From(resp *http.Response, opts ...FromOption) (*Details, *Type, error) {
+	d, err := From(resp, opts...)
+	if d == nil || err != nil {
+		return nil, nil, err
+	}
+
+	return d, r.Match(d), nil
+}
+
+// Describe returns a new [Details] for the registered type with the given URI and the given detail.
+//
+// If a type is found via [Registry.Lookup], the result is the same as calling [Type.Details] with [WithDetail].
+// Otherwise, the returned value only has the Type and Detail fields set.
+func (r *Registry) Describe(uri string, detail string) *Details {
+	if t := r.Lookup(uri); t != nil {
+		return t.Details(WithDetail(detail))
+	}
+
+	return &Details{Type: uri, Detail: detail}
+}
+
+// RegisterDefaultForStatus registers t as default type for the given status, replacing any previously registered
+// default for the status.
+//
+// The default type is used by [Registry.FromStatus]. Default types are kept separately from the types added via
+// [Registry.Register] and are neither returned by [Registry.Types] nor checked by [Registry.Validate].
+func (r *Registry) RegisterDefaultForStatus(status int, t *Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.defaults == nil {
+		r.defaults = make(map[int]*Type)
+	}
+
+	r.defaults[status] = t
+}
+
+// DefaultForStatus returns the default type registered for the given status or nil if there is none.
+func (r *Registry) DefaultForStatus(status int) *Type {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.defaults[status]
+}
+
+// FromStatus returns a new [Details] for the given status.
+//
+// If a default type was registered for the status using [Registry.RegisterDefaultForStatus], the result is the same
+// as calling [Type.Details] on the default type. Otherwise, a problem without a type is returned, with
+// [http.StatusText] used as title, same as with [Blank].
+//
+// In both cases the options are applied to the returned value.
+func (r *Registry) FromStatus(status int, opts ...Option) *Details {
+	if t := r.DefaultForStatus(status); t != nil {
+		return t.Details(opts...)
+	}
+
+	return New("", http.StatusText(status), status, opts...)
+}
+
+// Validate checks all registered types and returns an error describing all found issues, if any.
+//
+// A type is considered invalid if its title is empty, its URI can not be parsed as URI reference or if it has a
+// non-zero status outside the range 100 to 599. Additionally, any URI used by more than one type is reported.
+//
+// An empty URI is treated as "about:blank". See also [AboutBlankTypeURI].
+//
+// The returned error is created using [errors.Join] and contains one error per issue.
+func (r *Registry) Validate() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+
+	seen := make(map[string]int, len(r.types))
+
+	for _, t := range r.types {
+		uri := cmp.Or(t.URI, AboutBlankTypeURI)
+
+		if t.Title == "" {
+			errs = append(errs, fmt.Errorf("problem type %q: missing title", uri))
+		}
+
+		if _, err := url.Parse(uri); err != nil {
+			errs = append(errs, fmt.Errorf("problem type %q: invalid URI: %w", uri, err))
+		}
+
+		if t.Status != 0 && (t.Status < minStatus || t.Status > maxStatus) {
+			errs = append(errs, fmt.Errorf("problem type %q: status %d out of range", uri, t.Status))
+		}
+
+		if seen[uri]++; seen[uri] == 2 {
+			errs = append(errs, fmt.Errorf("problem type %q: duplicate URI", uri))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Track enables tracking of registered types for which [Type.Details] or [Type.Minimal] is called and returns a
+// function that disables tracking again.
+//
+// Tracked types can be retrieved using [Registry.Seen] and [Registry.Unseen], for example in tests, to assert that
+// all registered types are used or to find unused types. The results stay available after tracking was disabled and
+// are only reset when tracking is enabled again.
+//
+// Tracking is opt-in since it adds overhead to every call to [Type.Details]. It should be disabled once it is not
+// needed anymore, for example using t.Cleanup(r.Track()) in tests. Types are matched by pointer, so only calls on
+// the registered *Type values are tracked.
+//
+// Calling Track while tracking is already enabled only returns another function for disabling tracking.
+func (r *Registry) Track() (stop func()) {
+	trackingMu.Lock()
+	defer trackingMu.Unlock()
+
+	if !slices.Contains(trackingRegistries, r) {
+		r.seen.Clear()
+
+		trackingRegistries = append(trackingRegistries, r)
+		trackingEnabled.Store(true)
+	}
+
+	return sync.OnceFunc(r.untrack)
+}
+
+// untrack disables tracking for r.
+func (r *Registry) untrack() {
+	trackingMu.Lock()
+	defer trackingMu.Unlock()
+
+	trackingRegistries = slices.DeleteFunc(trackingRegistries, func(o *Registry) bool {
+		return o == r
+	})
+
+	trackingEnabled.Store(len(trackingRegistries) > 0)
+}
+
+// Seen returns all registered types that were used since tracking was enabled via [Registry.Track], in the order
+// they were registered.
+func (r *Registry) Seen() []*Type {
+	return r.filterSeen(true)
+}
+
+// Unseen returns all registered types that were not used since tracking was enabled via [Registry.Track], in the
+// order they were registered.
+func (r *Registry) Unseen() []*Type {
+	return r.filterSeen(false)
+}
+
+func (r *Registry) filterSeen(seen bool) []*Type {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var types []*Type
+
+	for _, t := range r.types {
+		if _, ok := r.seen.Load(t); ok == seen {
+			types = append(types, t)
+		}
+	}
+
+	return types
+}
+
+// markSeen records t as used in all registries that have tracking enabled.
+func markSeen(t *Type) {
+	trackingMu.RLock()
+	defer trackingMu.RUnlock()
+
+	for _, r := range trackingRegistries {
+		r.mu.RLock()
+		_, ok := r.registered[t]
+		r.mu.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		// Only store the type once, since storing is more expensive than loading and types are usually used often.
+		if _, loaded := r.seen.Load(t); !loaded {
+			r.seen.Store(t, struct{}{})
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package problem_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestRegistry_Types(t *testing.T) {
+	outOfCredit := &problem.Type{URI: "https://example.com/probs/out-of-credit"}
+	accountLocked := &problem.Type{URI: "https://example.com/probs/account-locked"}
+
+	var r problem.Registry
+	r.Register(outOfCredit)
+	r.Register(accountLocked)
+
+	if diff := cmp.Diff([]*problem.Type{outOfCredit, accountLocked}, r.Types()); diff != "" {
+		t.Errorf("Registry.Types() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		var r problem.Registry
+		r.Register(
+			&problem.Type{
+				URI:    "https://example.com/probs/out-of-credit",
+				Title:  "You do not have enough credit.",
+				Status: http.StatusForbidden,
+			},
+			&problem.Type{
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+			},
+		)
+
+		if err := r.Validate(); err != nil {
+			t.Errorf("got error %v, want nil", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var r problem.Registry
+		r.Register(
+			&problem.Type{
+				URI:    "https://example.com/probs/out-of-credit",
+				Status: http.StatusForbidden,
+			},
+			&problem.Type{
+				URI:    "https://example.com/probs/out-of-credit",
+				Title:  "You do not have enough credit.",
+				Status: 1000,
+			},
+			&problem.Type{
+				URI:   "%zz",
+				Title: "Invalid URI",
+			},
+		)
+
+		err := r.Validate()
+		if err == nil {
+			t.Fatal("expected error not returned")
+		}
+
+		got := strings.Split(err.Error(), "\n")
+		want := []string{
+			`problem type "https://example.com/probs/out-of-credit": missing title`,
+			`problem type "https://example.com/probs/out-of-credit": status 1000 out of range`,
+			`problem type "https://example.com/probs/out-of-credit": duplicate URI`,
+			`problem type "%zz": invalid URI: parse "%zz": invalid URL escape "%zz"`,
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Registry.Validate() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	outOfCredit := &problem.Type{URI: "https://example.com/probs/out-of-credit"}
+	aboutBlank := &problem.Type{Title: "About Blank"}
+
+	var r problem.Registry
+	r.Register(outOfCredit, aboutBlank)
+
+	if got := r.Lookup("https://example.com/probs/out-of-credit"); got != outOfCredit {
+		t.Errorf("got %v, want %v", got, outOfCredit)
+	}
+
+	if got := r.Lookup(problem.AboutBlankTypeURI); got != aboutBlank {
+		t.Errorf("got %v, want %v", got, aboutBlank)
+	}
+
+	if got := r.Lookup("https://example.com/probs/account-locked"); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestRegistry_From(t *testing.T) {
+	outOfCredit := &problem.Type{URI: "https://example.com/probs/out-of-credit", Title: "You do not have enough credit."}
+
+	var r problem.Registry
+	r.Register(outOfCredit)
+
+	t.Run("Known", func(t *testing.T) {
+		const body = `{"type": "https://example.com/probs/out-of-credit"}`
+
+		resp := newResponse(http.StatusForbidden, problem.ContentType, strings.NewReader(body))
+
+		d, typ, err := r.From(resp)
+		if err != nil {
+			t.Fatalf("got error %v, want nil", err)
+		}
+
+		if d == nil || d.Type != outOfCredit.URI {
+			t.Errorf("got details %v, want details with type %q", d, outOfCredit.URI)
+		}
+
+		if typ != outOfCredit {
+			t.Errorf("got type %v, want %v", typ, outOfCredit)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		const body = `{"type": "https://example.com/probs/account-locked"}`
+
+		resp := newResponse(http.StatusForbidden, problem.ContentType, strings.NewReader(body))
+
+		d, typ, err := r.From(resp)
+		if err != nil {
+			t.Fatalf("got error %v, want nil", err)
+		}
+
+		if d == nil {
+			t.Errorf("got nil details, want details")
+		}
+
+		if typ != nil {
+			t.Errorf("got type %v, want nil", typ)
+		}
+	})
+
+	t.Run("No problem", func(t *testing.T) {
+		resp := newResponse(http.StatusForbidden, "text/plain", strings.NewReader(`Forbidden`))
+
+		d, typ, err := r.From(resp)
+		if d != nil || typ != nil || err != nil {
+			t.Errorf("got %v, %v, %v, want nil, nil, nil", d, typ, err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		resp := newResponse(http.StatusForbidden, problem.ContentType, strings.NewReader(`invalid`))
+
+		if _, _, err := r.From(resp); err == nil {
+			t.Errorf("got nil error, want error")
+		}
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	problem.DefaultRegistry.Register(&problem.Type{
+		URI:    "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+	})
+
+	defer func() {
+		problem.DefaultRegistry = &problem.Registry{}
+	}()
+
+	t.Run("Registered", func(t *testing.T) {
+		got := problem.Describe(
+			"https://example.com/probs/out-of-credit",
+			"Your current balance is 30, but that costs 50.")
+
+		want := &problem.Details{
+			Type:   "https://example.com/probs/out-of-credit",
+			Title:  "You do not have enough credit.",
+			Status: http.StatusForbidden,
+			Detail: "Your current balance is 30, but that costs 50.",
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+			t.Errorf("Describe() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Unregistered", func(t *testing.T) {
+		got := problem.Describe(
+			"https://example.com/probs/account-locked",
+			"Your account is locked.")
+
+		want := &problem.Details{
+			Type:   "https://example.com/probs/account-locked",
+			Detail: "Your account is locked.",
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+			t.Errorf("Describe() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestRegistry_FromStatus(t *testing.T) {
+	var r problem.Registry
+	r.RegisterDefaultForStatus(http.StatusNotFound, &problem.Type{
+		URI:    "https://example.com/probs/not-found",
+		Title:  "The resource does not exist.",
+		Status: http.StatusNotFound,
+		Extensions: map[string]any{
+			"doc_url": "https://example.com/docs/not-found",
+		},
+	})
+
+	t.Run("Registered default", func(t *testing.T) {
+		got := r.FromStatus(http.StatusNotFound, problem.WithDetail("Account 12345 does not exist."))
+
+		want := &problem.Details{
+			Type:   "https://example.com/probs/not-found",
+			Title:  "The resource does not exist.",
+			Status: http.StatusNotFound,
+			Detail: "Account 12345 does not exist.",
+			Extensions: map[string]any{
+				"doc_url": "https://example.com/docs/not-found",
+			},
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+			t.Errorf("Registry.FromStatus() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("No default", func(t *testing.T) {
+		got := r.FromStatus(http.StatusConflict, problem.WithDetail("Account 12345 already exists."))
+
+		want := &problem.Details{
+			Title:  "Conflict",
+			Status: http.StatusConflict,
+			Detail: "Account 12345 already exists.",
+		}
+
+		if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+			t.Errorf("Registry.FromStatus() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestRegistry_Track(t *testing.T) {
+	outOfCredit := &problem.Type{URI: "https://example.com/probs/out-of-credit", Title: "You do not have enough credit."}
+	accountLocked := &problem.Type{URI: "https://example.com/probs/account-locked", Title: "Your account is locked."}
+	unregistered := &problem.Type{URI: "https://example.com/probs/unregistered", Title: "Unregistered."}
+
+	var r problem.Registry
+	r.Register(outOfCredit, accountLocked)
+
+	// Not tracked, since tracking is not enabled yet.
+	_ = accountLocked.Details()
+
+	stop := r.Track()
+	defer stop()
+
+	_ = outOfCredit.Details()
+	_ = unregistered.Details()
+
+	if diff := cmp.Diff([]*problem.Type{outOfCredit}, r.Seen()); diff != "" {
+		t.Errorf("Registry.Seen() mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]*problem.Type{accountLocked}, r.Unseen()); diff != "" {
+		t.Errorf("Registry.Unseen() mismatch (-want +got):\n%s", diff)
+	}
+
+	_ = accountLocked.Minimal()
+
+	if diff := cmp.Diff([]*problem.Type{outOfCredit, accountLocked}, r.Seen()); diff != "" {
+		t.Errorf("Registry.Seen() mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := r.Unseen(); len(got) != 0 {
+		t.Errorf("got unseen types %v, want none", got)
+	}
+
+	t.Run("Stop", func(t *testing.T) {
+		var r problem.Registry
+		r.Register(outOfCredit, accountLocked)
+
+		stop := r.Track()
+
+		_ = outOfCredit.Details()
+
+		stop()
+
+		// Not tracked anymore.
+		_ = accountLocked.Details()
+
+		if diff := cmp.Diff([]*problem.Type{outOfCredit}, r.Seen()); diff != "" {
+			t.Errorf("Registry.Seen() mismatch (-want +got):\n%s", diff)
+		}
+
+		// Tracking again resets the seen types.
+		t.Cleanup(r.Track())
+
+		if diff := cmp.Diff([]*problem.Type{outOfCredit, accountLocked}, r.Unseen()); diff != "" {
+			t.Errorf("Registry.Unseen() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package problem
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// RequestIDExtension is the name of the extension used by [Serve] to include the request ID set by
+	// [RequestIDHandler].
+	RequestIDExtension = "request_id"
+
+	// RequestIDHeader is the default header used by [RequestIDHandler].
+	RequestIDHeader = "X-Request-ID"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx that contains the given request ID.
+//
+// The ID can be retrieved using [RequestIDFromContext].
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by [ContextWithRequestID] or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDHandler wraps the given handler and makes sure that each request has a request ID.
+//
+// The ID is read from the given request header or, if the header is missing, a new random ID is generated. If header
+// is empty, [RequestIDHeader] is used.
+//
+// The ID is echoed in the same header on the response and stored in the request context, where it can be retrieved
+// using [RequestIDFromContext]. Any problem served using [Serve] with the request also includes the ID in the
+// [RequestIDExtension], unless the extension is already set.
+func RequestIDHandler(header string, next http.Handler) http.Handler {
+	header = cmp.Or(header, RequestIDHeader)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(header)
+		if id == "" {
+			id = newRequestID()
+		}
+
+		w.Header().Set(header, id)
+
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// withRequestID returns d with the request ID from the request context added as extension, if any.
+//
+// If an ID is found, a copy of d is returned, so that d itself is not modified.
+func withRequestID(r *http.Request, d *Details) *Details {
+	if r == nil {
+		return d
+	}
+
+	id := RequestIDFromContext(r.Context())
+	if id == "" {
+		return d
+	}
+
+	if _, ok := d.Extensions[RequestIDExtension]; ok {
+		return d
+	}
+
+	c := d.Clone()
+
+	if c.Extensions == nil {
+		c.Extensions = make(map[string]any, 1)
+	}
+
+	c.Extensions[RequestIDExtension] = id
+
+	return c
+}
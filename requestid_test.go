@@ -0,0 +1,65 @@
+package problem_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	notFound := &problem.Details{Status: http.StatusNotFound}
+
+	t.Run("Provided", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Correlation-ID", "abc")
+
+		problem.RequestIDHandler("X-Correlation-ID", notFound).ServeHTTP(w, r)
+
+		assertResponse(t, w, http.StatusNotFound, `{
+			"status": 404,
+			"request_id": "abc"
+		}`)
+
+		if got, want := w.Header().Get("X-Correlation-ID"), "abc"; got != want {
+			t.Errorf("got request ID %q, want %q", got, want)
+		}
+
+		if notFound.Extensions != nil {
+			t.Errorf("details were modified")
+		}
+	})
+
+	t.Run("Generated", func(t *testing.T) {
+		var ids []string
+
+		for range 2 {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ids = append(ids, problem.RequestIDFromContext(r.Context()))
+
+				notFound.ServeHTTP(w, r)
+			})
+
+			problem.RequestIDHandler("", handler).ServeHTTP(w, r)
+
+			id := w.Header().Get(problem.RequestIDHeader)
+			if len(id) != 32 {
+				t.Errorf("got request ID %q, want 32 hex characters", id)
+			}
+
+			assertResponse(t, w, http.StatusNotFound, `{
+				"status": 404,
+				"request_id": "`+id+`"
+			}`)
+		}
+
+		if ids[0] == ids[1] {
+			t.Errorf("got same request ID %q for different requests", ids[0])
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ValidateOption defines functional options that can be used to customize the checks done by [Details.Validate].
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	extensionKeyPattern *regexp.Regexp
+}
+
+// WithExtensionKeyPattern causes [Details.Validate] to report all extension keys that do not match the given pattern.
+//
+// Example:
+//
+//	// Only allow snake_case extension keys.
+//	err := d.Validate(problem.WithExtensionKeyPattern(regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)))
+func WithExtensionKeyPattern(pattern *regexp.Regexp) ValidateOption {
+	return func(o *validateOptions) {
+		o.extensionKeyPattern = pattern
+	}
+}
+
+// Validate checks d for common mistakes and returns an error describing all found issues, if any.
+//
+// By default, Validate checks that d conforms to RFC 9457 and reports
+//
+//   - a type or instance that is not a valid URI reference,
+//   - a non-zero status outside the range 100 to 599 and
+//   - extensions that use one of the [ReservedMembers] as key, since these are ignored when marshaling by default.
+//
+// The zero value is valid, since an empty type is equivalent to "about:blank". Additional checks can be enabled
+// using options.
+//
+// Validate is mainly meant to be used in tests or before serving a problem, for example to enforce a consistent
+// style for extension keys.
+//
+// The returned error is created using [errors.Join] and contains one error per issue.
+func (d *Details) Validate(opts ...ValidateOption) error {
+	var o validateOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var errs []error
+
+	if err := checkURIReference(d.Type); err != nil {
+		errs = append(errs, fmt.Errorf("type %q is not a valid URI reference: %w", d.Type, err))
+	}
+
+	if d.Status != 0 && (d.Status < minStatus || d.Status > maxStatus) {
+		errs = append(errs, fmt.Errorf("status %d out of range", d.Status))
+	}
+
+	if err := checkURIReference(d.Instance); err != nil {
+		errs = append(errs, fmt.Errorf("instance %q is not a valid URI reference: %w", d.Instance, err))
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(d.Extensions)) {
+		if isReservedMember(k) {
+			errs = append(errs, fmt.Errorf("extension %q collides with reserved member", k))
+			continue
+		}
+
+		if o.extensionKeyPattern != nil && !o.extensionKeyPattern.MatchString(k) {
+			errs = append(errs, fmt.Errorf("extension %q does not match pattern %q", k, o.extensionKeyPattern))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkURIReference returns an error if s is not a valid URI reference as defined by RFC 3986.
+//
+// In addition to parsing s using [url.Parse], which accepts some characters that are not allowed in URIs, this checks
+// that s only contains characters allowed by RFC 3986.
+func checkURIReference(s string) error {
+	for i := range len(s) {
+		if !isURIChar(s[i]) {
+			return fmt.Errorf("invalid character %q at index %d", s[i], i)
+		}
+	}
+
+	_, err := url.Parse(s)
+	return err
+}
+
+// isURIChar reports whether c is an unreserved or reserved character or the percent sign used for percent-encoding.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3986#section-2
+func isURIChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	default:
+		return strings.IndexByte("-._~:/?#[]@!$&'()*+,;=%", c) >= 0
+	}
+}
@@ -0,0 +1,140 @@
+package problem_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+var snakeCasePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+func TestDetails_Validate(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Details problem.Details
+		Opts    []problem.ValidateOption
+		Want    []string
+	}{
+		{
+			Name:    "Empty",
+			Details: problem.Details{},
+		},
+		{
+			Name: "Conforming extension key",
+			Details: problem.Details{
+				Extensions: map[string]any{"account_balance": 30},
+			},
+			Opts: []problem.ValidateOption{problem.WithExtensionKeyPattern(snakeCasePattern)},
+		},
+		{
+			Name: "Non-conforming extension key",
+			Details: problem.Details{
+				Extensions: map[string]any{"accountBalance": 30},
+			},
+			Opts: []problem.ValidateOption{problem.WithExtensionKeyPattern(snakeCasePattern)},
+			Want: []string{
+				`extension "accountBalance" does not match pattern "^[a-z][a-z0-9]*(_[a-z0-9]+)*$"`,
+			},
+		},
+		{
+			Name: "Valid",
+			Details: problem.Details{
+				Type:     "https://example.com/probs/out-of-credit",
+				Status:   403,
+				Title:    "You do not have enough credit.",
+				Instance: "/account/12345/msgs/abc",
+			},
+		},
+		{
+			Name: "Invalid type",
+			Details: problem.Details{
+				Type: "https://exa mple.com/probs/out-of-credit",
+			},
+			Want: []string{
+				`type "https://exa mple.com/probs/out-of-credit" is not a valid URI reference: ` +
+					`invalid character ' ' at index 11`,
+			},
+		},
+		{
+			Name: "Space in path",
+			Details: problem.Details{
+				Type: "https://example.com/probs/out of credit",
+			},
+			Want: []string{
+				`type "https://example.com/probs/out of credit" is not a valid URI reference: ` +
+					`invalid character ' ' at index 29`,
+			},
+		},
+		{
+			Name: "Not a URI",
+			Details: problem.Details{
+				Type:     "not a uri at all",
+				Instance: `/account/{id}`,
+			},
+			Want: []string{
+				`type "not a uri at all" is not a valid URI reference: invalid character ' ' at index 3`,
+				`instance "/account/{id}" is not a valid URI reference: invalid character '{' at index 9`,
+			},
+		},
+		{
+			Name:    "Status too low",
+			Details: problem.Details{Status: 99},
+			Want:    []string{`status 99 out of range`},
+		},
+		{
+			Name:    "Status too high",
+			Details: problem.Details{Status: 600},
+			Want:    []string{`status 600 out of range`},
+		},
+		{
+			Name: "Invalid instance",
+			Details: problem.Details{
+				Instance: "%zz",
+			},
+			Want: []string{
+				`instance "%zz" is not a valid URI reference: parse "%zz": invalid URL escape "%zz"`,
+			},
+		},
+		{
+			Name: "Multiple",
+			Details: problem.Details{
+				Status:     1000,
+				Instance:   "%zz",
+				Extensions: map[string]any{"type": "other"},
+			},
+			Want: []string{
+				`status 1000 out of range`,
+				`instance "%zz" is not a valid URI reference: parse "%zz": invalid URL escape "%zz"`,
+				`extension "type" collides with reserved member`,
+			},
+		},
+		{
+			Name: "Reserved extension key",
+			Details: problem.Details{
+				Extensions: map[string]any{"status": 403, "title": "Forbidden"},
+			},
+			Want: []string{
+				`extension "status" collides with reserved member`,
+				`extension "title" collides with reserved member`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var got []string
+
+			if err := test.Details.Validate(test.Opts...); err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("Details.Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package problem
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// InvalidParamsExtension is the name of the extension used by [ValidationBuilder] to store invalid parameters.
+	//
+	// See also https://datatracker.ietf.org/doc/html/rfc9457#name-extension-members
+	InvalidParamsExtension = "invalid-params"
+)
+
+// InvalidParam describes a single invalid parameter as part of the [InvalidParamsExtension].
+type InvalidParam struct {
+	// Name is the name of the invalid parameter.
+	Name string `json:"name"`
+
+	// Reason is a human-readable explanation why the parameter is invalid.
+	Reason string `json:"reason"`
+}
+
+// UnprocessableEntity returns a new [Details] for a "422 Unprocessable Entity" response, with the given parameters
+// stored in the [InvalidParamsExtension].
+//
+// Unlike a "400 Bad Request", this status is meant for requests that are syntactically valid, but semantically
+// invalid. If no parameters are given, the extension is not set.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9110#name-422-unprocessable-content
+func UnprocessableEntity(params ...InvalidParam) *Details {
+	d := New("", http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+
+	if len(params) > 0 {
+		WithExtension(InvalidParamsExtension, params)(d)
+	}
+
+	return d
+}
+
+// ValidationBuilder can be used to incrementally collect invalid parameters and create a [Details] containing
+// all collected parameters.
+//
+// Example:
+//
+//	b := problem.NewValidationBuilder(ValidationProblemType)
+//
+//	for _, item := range items {
+//		if item.Age < 0 {
+//			b.Add(item.Name+".age", "must be a positive integer")
+//		}
+//	}
+//
+//	if b.Len() > 0 {
+//		b.Done().ServeHTTP(w, r)
+//		return
+//	}
+type ValidationBuilder struct {
+	typ    *Type
+	opts   []Option
+	params []InvalidParam
+	merge  bool
+}
+
+// NewValidationBuilder returns a new ValidationBuilder that creates problems from the given type and options.
+func NewValidationBuilder(t *Type, opts ...Option) *ValidationBuilder {
+	return &ValidationBuilder{typ: t, opts: opts}
+}
+
+// Grow grows the internal buffer so that at least n more parameters can be added without another allocation.
+func (b *ValidationBuilder) Grow(n int) {
+	if cap(b.params)-len(b.params) >= n {
+		return
+	}
+
+	params := make([]InvalidParam, len(b.params), len(b.params)+n)
+	copy(params, b.params)
+	b.params = params
+}
+
+// Add adds a new invalid parameter with the given name and reason.
+func (b *ValidationBuilder) Add(name, reason string) {
+	b.params = append(b.params, InvalidParam{Name: name, Reason: reason})
+}
+
+// MergeDuplicates sets whether [ValidationBuilder.Done] merges parameters with the same name into a single entry.
+//
+// When enabled, the reasons of all parameters with the same name are combined into a single reason, separated by
+// "; ", with identical reasons only included once. The merged entry keeps the position of the first parameter with
+// that name.
+//
+// By default, all added parameters are kept as is.
+func (b *ValidationBuilder) MergeDuplicates(merge bool) {
+	b.merge = merge
+}
+
+// Len returns the number of invalid parameters added so far.
+func (b *ValidationBuilder) Len() int {
+	return len(b.params)
+}
+
+// Done returns a new [Details] created from the builders type and options, with all added parameters stored in
+// the [InvalidParamsExtension].
+//
+// If no parameters were added, the extension is not set.
+//
+// After calling Done, the builder is reset and can be reused.
+func (b *ValidationBuilder) Done() *Details {
+	d := b.typ.Details(b.opts...)
+
+	params := b.params
+
+	if b.merge {
+		params = mergeInvalidParams(params)
+	}
+
+	if len(params) > 0 {
+		WithExtension(InvalidParamsExtension, params)(d)
+	}
+
+	b.params = nil
+
+	return d
+}
+
+func mergeInvalidParams(params []InvalidParam) []InvalidParam {
+	merged := make([]InvalidParam, 0, len(params))
+	reasons := make(map[string][]string, len(params))
+	indices := make(map[string]int, len(params))
+
+	for _, p := range params {
+		i, ok := indices[p.Name]
+		if !ok {
+			indices[p.Name] = len(merged)
+			reasons[p.Name] = []string{p.Reason}
+			merged = append(merged, p)
+			continue
+		}
+
+		if slices.Contains(reasons[p.Name], p.Reason) {
+			continue
+		}
+
+		reasons[p.Name] = append(reasons[p.Name], p.Reason)
+		merged[i].Reason = strings.Join(reasons[p.Name], "; ")
+	}
+
+	return merged
+}
@@ -0,0 +1,140 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+var validationProblemType = &problem.Type{
+	URI:    "https://example.net/validation-error",
+	Title:  "Your request is not valid.",
+	Status: http.StatusBadRequest,
+}
+
+func TestValidationBuilder(t *testing.T) {
+	b := problem.NewValidationBuilder(validationProblemType, problem.WithInstance("/users"))
+	b.Grow(2)
+	b.Add("age", "must be a positive integer")
+	b.Add("color", "must be 'green', 'red' or 'blue'")
+
+	if got, want := b.Len(), 2; got != want {
+		t.Errorf("got length %d, want %d", got, want)
+	}
+
+	got := b.Done()
+
+	want := &problem.Details{
+		Type:     "https://example.net/validation-error",
+		Title:    "Your request is not valid.",
+		Status:   http.StatusBadRequest,
+		Instance: "/users",
+		Extensions: map[string]any{
+			problem.InvalidParamsExtension: []problem.InvalidParam{
+				{Name: "age", Reason: "must be a positive integer"},
+				{Name: "color", Reason: "must be 'green', 'red' or 'blue'"},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, got, ignoreUnexported); diff != "" {
+		t.Errorf("ValidationBuilder.Done() mismatch (-want +got):\n%s", diff)
+	}
+
+	body, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"type": "https://example.net/validation-error",
+		"title": "Your request is not valid.",
+		"status": 400,
+		"instance": "/users",
+		"invalid-params": [
+			{"name": "age", "reason": "must be a positive integer"},
+			{"name": "color", "reason": "must be 'green', 'red' or 'blue'"}
+		]
+	}`, body)
+
+	if got, want := b.Len(), 0; got != want {
+		t.Errorf("got length %d after Done(), want %d", got, want)
+	}
+
+	if got := b.Done().Extensions; got != nil {
+		t.Errorf("got extensions %v for empty builder, want nil", got)
+	}
+}
+
+func TestValidationBuilder_MergeDuplicates(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Merge bool
+		Want  []problem.InvalidParam
+	}{
+		{
+			Name:  "Keep all",
+			Merge: false,
+			Want: []problem.InvalidParam{
+				{Name: "age", Reason: "must be a positive integer"},
+				{Name: "color", Reason: "must be 'green', 'red' or 'blue'"},
+				{Name: "age", Reason: "must be at least 18"},
+				{Name: "age", Reason: "must be a positive integer"},
+			},
+		},
+		{
+			Name:  "Merge",
+			Merge: true,
+			Want: []problem.InvalidParam{
+				{Name: "age", Reason: "must be a positive integer; must be at least 18"},
+				{Name: "color", Reason: "must be 'green', 'red' or 'blue'"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			b := problem.NewValidationBuilder(validationProblemType)
+			b.MergeDuplicates(test.Merge)
+			b.Add("age", "must be a positive integer")
+			b.Add("color", "must be 'green', 'red' or 'blue'")
+			b.Add("age", "must be at least 18")
+			b.Add("age", "must be a positive integer")
+
+			got := b.Done().Extensions[problem.InvalidParamsExtension]
+
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("ValidationBuilder.Done() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnprocessableEntity(t *testing.T) {
+	got := problem.UnprocessableEntity(
+		problem.InvalidParam{Name: "start", Reason: "must be before end"},
+		problem.InvalidParam{Name: "end", Reason: "must be after start"},
+	)
+
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	assertJSON(t, `{
+		"status": 422,
+		"title": "Unprocessable Entity",
+		"invalid-params": [
+			{"name": "start", "reason": "must be before end"},
+			{"name": "end", "reason": "must be after start"}
+		]
+	}`, b)
+
+	if got := problem.UnprocessableEntity().Extensions; got != nil {
+		t.Errorf("got extensions %v without parameters, want nil", got)
+	}
+}
@@ -0,0 +1,238 @@
+package problem
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+const (
+	// ContentTypeXML is the media type used for problem responses encoded as XML, as defined by IANA.
+	//
+	// See also https://datatracker.ietf.org/doc/html/rfc9457#name-xml-schema-and-format
+	ContentTypeXML = "application/problem+xml"
+
+	// XMLNamespace is the XML namespace used for problems encoded as XML.
+	XMLNamespace = "urn:ietf:rfc:7807"
+)
+
+// prefersXML reports whether the given Accept header value lists application/problem+xml with a higher quality than
+// any JSON media type.
+func prefersXML(accept string) bool {
+	xmlQ := acceptQuality(accept, ContentTypeXML)
+	return xmlQ > 0 && xmlQ > acceptQuality(accept, ContentType, "application/json")
+}
+
+var (
+	_ xml.Marshaler   = (*Details)(nil)
+	_ xml.Unmarshaler = (*Details)(nil)
+)
+
+// MarshalXML implements the [xml.Marshaler] interface.
+//
+// The problem is encoded as "problem" element in the [XMLNamespace] as described in RFC 9457, regardless of the
+// name of the given start element. Extensions are encoded as child elements named after their key. Arrays are
+// encoded using child elements named "i" for each value.
+//
+// An error is returned if the key of an extension, or of an object nested inside an extension, is not a valid XML
+// element name, for example because it contains spaces or starts with a digit.
+//
+// See also https://datatracker.ietf.org/doc/html/rfc9457#name-xml-schema-and-format
+func (d *Details) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return d.encodeXML(enc, &encodeOptions{})
+}
+
+// UnmarshalXML implements the [xml.Unmarshaler] interface.
+//
+// Child elements for the members defined by RFC 9457 are decoded into the respective fields. A status that is not a
+// valid integer is ignored, same as for JSON. All other child elements are stored in Extensions, using the text
+// content of the element as string value. Nested elements are ignored.
+func (d *Details) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	var problem struct {
+		Elements []struct {
+			XMLName xml.Name
+			Text    string `xml:",chardata"`
+		} `xml:",any"`
+	}
+
+	if err := dec.DecodeElement(&problem, &start); err != nil {
+		return err
+	}
+
+	for _, elem := range problem.Elements {
+		switch name := elem.XMLName.Local; name {
+		case "type":
+			d.Type = elem.Text
+		case "status":
+			if status, err := strconv.Atoi(strings.TrimSpace(elem.Text)); err == nil {
+				d.Status = status
+			}
+		case "title":
+			d.Title = elem.Text
+		case "detail":
+			d.Detail = elem.Text
+		case "instance":
+			d.Instance = elem.Text
+		default:
+			if d.Extensions == nil {
+				d.Extensions = make(map[string]any)
+			}
+
+			d.Extensions[name] = elem.Text
+		}
+	}
+
+	return nil
+}
+
+func marshalXML(d *Details, o *encodeOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+
+	if err := d.encodeXML(enc, o); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeXML writes d as "problem" element to enc.
+//
+// The problem is first encoded as JSON using the given options, which is then converted into XML as described in
+// RFC 9457, so that both formats always contain the same members.
+func (d *Details) encodeXML(enc *xml.Encoder, o *encodeOptions) error {
+	b, err := marshal(d, o)
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{
+		Name: xml.Name{Local: "problem"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: XMLNamespace}},
+	}
+
+	if err := jsonToXML(jsontext.NewDecoder(bytes.NewReader(b)), enc, start); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+// jsonToXML reads the next JSON value from dec and writes it as element to enc.
+//
+// Object members are written as child elements named after the member and array elements are written as child
+// elements named "i", as in the examples in RFC 9457. An error is returned for members whose name is not a valid
+// XML element name.
+func jsonToXML(dec *jsontext.Decoder, enc *xml.Encoder, start xml.StartElement) error {
+	tok, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch tok.Kind() {
+	case '{':
+		for dec.PeekKind() != '}' {
+			name, err := dec.ReadToken()
+			if err != nil {
+				return err
+			}
+
+			if !isXMLName(name.String()) {
+				return fmt.Errorf("problem: member %q is not a valid XML element name", name.String())
+			}
+
+			if err := jsonToXML(dec, enc, xml.StartElement{Name: xml.Name{Local: name.String()}}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := dec.ReadToken(); err != nil {
+			return err
+		}
+	case '[':
+		for dec.PeekKind() != ']' {
+			if err := jsonToXML(dec, enc, xml.StartElement{Name: xml.Name{Local: "i"}}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := dec.ReadToken(); err != nil {
+			return err
+		}
+	case 'n':
+		// Write an empty element.
+	case '"', '0', 't', 'f':
+		if err := enc.EncodeToken(xml.CharData(tok.String())); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("problem: unexpected JSON token %v", tok)
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// isXMLName reports whether s is a valid XML element name without namespace prefix, as defined by the NCName
+// production in https://www.w3.org/TR/xml-names/#NT-NCName.
+func isXMLName(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+
+	for i, r := range s {
+		if !isXMLNameStartChar(r) && (i == 0 || !isXMLNameChar(r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isXMLNameStartChar reports whether r is allowed as first character of an XML name, excluding ":".
+//
+// See https://www.w3.org/TR/xml/#NT-NameStartChar
+func isXMLNameStartChar(r rune) bool {
+	switch {
+	case r >= 'A' && r <= 'Z', r == '_', r >= 'a' && r <= 'z':
+		return true
+	case r >= 0xC0 && r <= 0xD6, r >= 0xD8 && r <= 0xF6, r >= 0xF8 && r <= 0x2FF:
+		return true
+	case r >= 0x370 && r <= 0x37D, r >= 0x37F && r <= 0x1FFF, r >= 0x200C && r <= 0x200D:
+		return true
+	case r >= 0x2070 && r <= 0x218F, r >= 0x2C00 && r <= 0x2FEF, r >= 0x3001 && r <= 0xD7FF:
+		return true
+	case r >= 0xF900 && r <= 0xFDCF, r >= 0xFDF0 && r <= 0xFFFD, r >= 0x10000 && r <= 0xEFFFF:
+		return true
+	default:
+		return false
+	}
+}
+
+// isXMLNameChar reports whether r is allowed after the first character of an XML name, excluding ":".
+//
+// See https://www.w3.org/TR/xml/#NT-NameChar
+func isXMLNameChar(r rune) bool {
+	switch {
+	case r == '-', r == '.', r >= '0' && r <= '9', r == 0xB7:
+		return true
+	case r >= 0x300 && r <= 0x36F, r >= 0x203F && r <= 0x2040:
+		return true
+	default:
+		return isXMLNameStartChar(r)
+	}
+}
@@ -0,0 +1,276 @@
+package problem_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nussjustin/problem"
+)
+
+func TestDetails_ServeHTTP_XML(t *testing.T) {
+	details := &problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"accounts": []string{"/account/12345", "/account/67890"},
+		},
+	}
+
+	t.Run("XML", func(t *testing.T) {
+		accepts := []string{
+			"application/problem+xml",
+			"application/problem+json;q=0.5, application/problem+xml",
+		}
+
+		for _, accept := range accepts {
+			t.Run(accept, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+
+				details.ServeHTTP(w, r)
+
+				if got, want := w.Code, http.StatusForbidden; got != want {
+					t.Errorf("got status %d, want %d", got, want)
+				}
+
+				if got, want := w.Header().Get("Content-Type"), problem.ContentTypeXML; got != want {
+					t.Errorf("got Content-Type %q, want %q", got, want)
+				}
+
+				if got, want := w.Header().Get("Vary"), "Accept"; got != want {
+					t.Errorf("got Vary %q, want %q", got, want)
+				}
+
+				want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+					`<problem xmlns="urn:ietf:rfc:7807">` +
+					`<type>https://example.com/probs/out-of-credit</type>` +
+					`<status>403</status>` +
+					`<title>You do not have enough credit.</title>` +
+					`<detail>Your current balance is 30, but that costs 50.</detail>` +
+					`<instance>/account/12345/msgs/abc</instance>` +
+					`<accounts><i>/account/12345</i><i>/account/67890</i></accounts>` +
+					`</problem>`
+
+				if got := w.Body.String(); got != want {
+					t.Errorf("got body %s, want %s", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		accepts := []string{
+			"",
+			"*/*",
+			"text/html",
+			"application/problem+json, application/problem+xml",
+			"application/problem+json, application/problem+xml;q=0.5",
+		}
+
+		for _, accept := range accepts {
+			t.Run(accept, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+
+				details.ServeHTTP(w, r)
+
+				assertResponse(t, w, http.StatusForbidden, `{
+					"type": "https://example.com/probs/out-of-credit",
+					"title": "You do not have enough credit.",
+					"status": 403,
+					"detail": "Your current balance is 30, but that costs 50.",
+					"instance": "/account/12345/msgs/abc",
+					"accounts": ["/account/12345", "/account/67890"]
+				}`)
+			})
+		}
+	})
+
+	t.Run("Extension values", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", problem.ContentTypeXML)
+
+		problem.New("", "Escaped <title>", 0,
+			problem.WithExtension("values", []any{
+				map[string]any{"balance": 30.5},
+				true,
+				nil,
+			}),
+		).ServeHTTP(w, r)
+
+		want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<problem xmlns="urn:ietf:rfc:7807">` +
+			`<title>Escaped &lt;title&gt;</title>` +
+			`<values><i><balance>30.5</balance></i><i>true</i><i></i></values>` +
+			`</problem>`
+
+		if got := w.Body.String(); got != want {
+			t.Errorf("got body %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Invalid extension key", func(t *testing.T) {
+		defer func() {
+			problem.OnMarshalError = nil
+		}()
+
+		var marshalErr error
+
+		problem.OnMarshalError = func(_ *problem.Details, err error) *problem.Details {
+			marshalErr = err
+			return problem.InternalServerError
+		}
+
+		for _, key := range []string{"foo bar", "1abc", "a<b", "ns:key"} {
+			t.Run(key, func(t *testing.T) {
+				marshalErr = nil
+
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", problem.ContentTypeXML)
+
+				problem.New("", "Forbidden", http.StatusForbidden, problem.WithExtension(key, "value")).ServeHTTP(w, r)
+
+				if marshalErr == nil {
+					t.Error("OnMarshalError was not called")
+				}
+
+				if got, want := w.Code, http.StatusInternalServerError; got != want {
+					t.Errorf("got status %d, want %d", got, want)
+				}
+
+				var got problem.Details
+
+				if err := xml.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("failed to unmarshal response: %s", err)
+				}
+
+				if got, want := got.Status, http.StatusInternalServerError; got != want {
+					t.Errorf("got problem status %d, want %d", got, want)
+				}
+			})
+		}
+	})
+}
+
+func TestDetails_MarshalXML(t *testing.T) {
+	d := problem.Details{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"balance": 30,
+		},
+	}
+
+	b, err := xml.Marshal(&d)
+	if err != nil {
+		t.Fatalf("failed to marshal details: %s", err)
+	}
+
+	want := `<problem xmlns="urn:ietf:rfc:7807">` +
+		`<type>https://example.com/probs/out-of-credit</type>` +
+		`<status>403</status>` +
+		`<title>You do not have enough credit.</title>` +
+		`<detail>Your current balance is 30, but that costs 50.</detail>` +
+		`<instance>/account/12345/msgs/abc</instance>` +
+		`<balance>30</balance>` +
+		`</problem>`
+
+	if got := string(b); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	var got problem.Details
+
+	if err := xml.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to unmarshal details: %s", err)
+	}
+
+	// Extensions are always decoded as strings.
+	d.Extensions["balance"] = "30"
+
+	if diff := cmp.Diff(d, got, ignoreUnexported); diff != "" {
+		t.Errorf("xml.Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("Invalid extension key", func(t *testing.T) {
+		for _, ext := range []map[string]any{
+			{"foo bar": 1},
+			{"1abc": 1},
+			{"a<b": 1},
+			{"nested": map[string]any{"foo bar": 1}},
+		} {
+			d := problem.Details{Status: http.StatusForbidden, Extensions: ext}
+
+			if b, err := xml.Marshal(&d); err == nil {
+				t.Errorf("got %s for extensions %v, want error", b, ext)
+			}
+		}
+	})
+}
+
+func TestDetails_UnmarshalXML(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Input string
+		Want  problem.Details
+	}{
+		{
+			Name:  "Empty",
+			Input: `<problem xmlns="urn:ietf:rfc:7807"></problem>`,
+			Want:  problem.Details{},
+		},
+		{
+			Name: "Full",
+			Input: `<?xml version="1.0" encoding="UTF-8"?>
+				<problem xmlns="urn:ietf:rfc:7807">
+					<type>https://example.com/probs/out-of-credit</type>
+					<title>You do not have enough credit.</title>
+					<status> 403 </status>
+					<detail>Your current balance is 30, but that costs 50.</detail>
+					<instance>/account/12345/msgs/abc</instance>
+					<balance>30</balance>
+				</problem>`,
+			Want: problem.Details{
+				Type:       "https://example.com/probs/out-of-credit",
+				Title:      "You do not have enough credit.",
+				Status:     http.StatusForbidden,
+				Detail:     "Your current balance is 30, but that costs 50.",
+				Instance:   "/account/12345/msgs/abc",
+				Extensions: map[string]any{"balance": "30"},
+			},
+		},
+		{
+			Name:  "Invalid status",
+			Input: `<problem xmlns="urn:ietf:rfc:7807"><status>forbidden</status></problem>`,
+			Want:  problem.Details{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var got problem.Details
+
+			if err := xml.Unmarshal([]byte(test.Input), &got); err != nil {
+				t.Fatalf("failed to unmarshal input: %s", err)
+			}
+
+			if diff := cmp.Diff(test.Want, got, ignoreUnexported); diff != "" {
+				t.Errorf("xml.Unmarshal() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}